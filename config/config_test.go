@@ -565,7 +565,8 @@ var expectedConf = &Config{
 						FollowRedirects: true,
 						EnableHTTP2:     true,
 					},
-					NamespaceDiscovery: kubernetes.NamespaceDiscovery{},
+					NamespaceDiscovery:  kubernetes.NamespaceDiscovery{},
+					AllowWatchBookmarks: true,
 				},
 			},
 		},
@@ -609,7 +610,8 @@ var expectedConf = &Config{
 							"default",
 						},
 					},
-					HTTPClientConfig: config.DefaultHTTPClientConfig,
+					HTTPClientConfig:    config.DefaultHTTPClientConfig,
+					AllowWatchBookmarks: true,
 				},
 			},
 		},
@@ -2009,6 +2011,10 @@ func TestKubernetesSelectors(t *testing.T) {
 	require.NoError(t, err)
 	_, err = LoadFile("testdata/kubernetes_selectors_service.good.yml", false, promslog.NewNopLogger())
 	require.NoError(t, err)
+	_, err = LoadFile("testdata/kubernetes_selectors_structured.good.yml", false, promslog.NewNopLogger())
+	require.NoError(t, err)
+	_, err = LoadFile("testdata/kubernetes_selectors_namespaces.good.yml", false, promslog.NewNopLogger())
+	require.NoError(t, err)
 }
 
 var expectedErrors = []struct {
@@ -2191,6 +2197,58 @@ var expectedErrors = []struct {
 		filename: "kubernetes_selectors_duplicated_role.bad.yml",
 		errMsg:   "duplicated selector role: pod",
 	},
+	{
+		filename: "kubernetes_selectors_structured.bad.yml",
+		errMsg:   "only one of 'label' and 'match_labels'/'match_expressions' can be set in a pod selector",
+	},
+	{
+		filename: "kubernetes_selectors_namespaces.bad.yml",
+		errMsg:   `multiple pod selectors cover namespace "monitoring"`,
+	},
+	{
+		filename: "kubernetes_kube_context.bad.yml",
+		errMsg:   "'kube_context' requires 'kubeconfig_file' to be set",
+	},
+	{
+		filename: "kubernetes_default_metrics_port.bad.yml",
+		errMsg:   "'default_metrics_port' is only supported for the pod role",
+	},
+	{
+		filename: "kubernetes_namespace_exclude_names.bad.yml",
+		errMsg:   "'names' and 'exclude_names' are mutually exclusive",
+	},
+	{
+		filename: "kubernetes_resync_period.bad.yml",
+		errMsg:   "'resync_period' must be either zero or at least one minute",
+	},
+	{
+		filename: "kubernetes_list_page_size.bad.yml",
+		errMsg:   "'list_page_size' must not be negative",
+	},
+	{
+		filename: "kubernetes_node_address_priority.bad.yml",
+		errMsg:   `invalid IP family "IPvX" in 'node_address_priority', expecting IPv4 or IPv6`,
+	},
+	{
+		filename: "kubernetes_selector_from_configmap.bad.yml",
+		errMsg:   "'selector_from_configmap' requires 'namespace', 'name' and 'key' to be set",
+	},
+	{
+		filename: "kubernetes_custom_resource.bad.yml",
+		errMsg:   "'custom_resource' must be set for the customresource role",
+	},
+	{
+		filename: "kubernetes_selector_from_custom_resource.bad.yml",
+		errMsg:   "'selector_from_custom_resource' requires 'group', 'version' and 'resource' to be set",
+	},
+	{
+		filename: "kubernetes_user_agent_suffix.bad.yml",
+		errMsg:   "'user_agent_suffix' must not contain control characters",
+	},
+	{
+		filename: "kubernetes_selectors_unsupported_field.bad.yml",
+		errMsg:   `field selector "spec.nodeName" is not supported for service resources`,
+	},
 	{
 		filename: "kubernetes_selectors_incorrect_selector.bad.yml",
 		errMsg:   "invalid selector: 'metadata.status-Running'; can't understand 'metadata.status-Running'",
@@ -0,0 +1,203 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// ConfigMap discovers the static targets curated in a single ConfigMap key,
+// in the same list format file-based discovery reads: a YAML or JSON list of
+// groups with targets and labels. It is essentially file_sd sourced from the
+// cluster, for target lists maintained with kubectl rather than on the
+// Prometheus host.
+type ConfigMap struct {
+	logger   *slog.Logger
+	informer cache.SharedInformer
+	store    cache.Store
+	queue    *workqueue.Type
+	key      string
+
+	// lastLength is the group count of the last good parse, so that sources
+	// a since-shrunken list no longer produces are cleared downstream.
+	lastLength int
+}
+
+// NewConfigMap returns a new configmap targets discovery.
+func NewConfigMap(l *slog.Logger, inf cache.SharedInformer, key string, eventCount *prometheus.CounterVec) *ConfigMap {
+	if l == nil {
+		l = promslog.NewNopLogger()
+	}
+
+	cmAddCount := eventCount.WithLabelValues(RoleConfigMap.String(), MetricLabelRoleAdd)
+	cmUpdateCount := eventCount.WithLabelValues(RoleConfigMap.String(), MetricLabelRoleUpdate)
+	cmDeleteCount := eventCount.WithLabelValues(RoleConfigMap.String(), MetricLabelRoleDelete)
+
+	c := &ConfigMap{
+		logger:   l,
+		informer: inf,
+		store:    inf.GetStore(),
+		queue:    workqueue.NewNamed(RoleConfigMap.String()),
+		key:      key,
+	}
+
+	_, err := c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(o interface{}) {
+			cmAddCount.Inc()
+			c.enqueue(o)
+		},
+		DeleteFunc: func(o interface{}) {
+			cmDeleteCount.Inc()
+			c.enqueue(o)
+		},
+		UpdateFunc: func(_, o interface{}) {
+			cmUpdateCount.Inc()
+			c.enqueue(o)
+		},
+	})
+	if err != nil {
+		l.Error("Error adding configmaps event handler.", "err", err)
+	}
+
+	return c
+}
+
+func (c *ConfigMap) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+
+	c.queue.Add(key)
+}
+
+// Run implements the Discoverer interface.
+func (c *ConfigMap) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
+	defer c.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			c.logger.Error("configmap informer unable to sync cache")
+		}
+		return
+	}
+
+	go func() {
+		for c.process(ctx, ch) {
+		}
+	}()
+
+	// Block until the target provider is explicitly canceled.
+	<-ctx.Done()
+}
+
+func (c *ConfigMap) process(ctx context.Context, ch chan<- []*targetgroup.Group) bool {
+	keyObj, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(keyObj)
+	key := keyObj.(string)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return true
+	}
+
+	o, exists, err := c.store.GetByKey(key)
+	if err != nil {
+		return true
+	}
+	if !exists {
+		// The ConfigMap is gone, clear all groups it produced.
+		c.sendGroups(ctx, ch, nil, namespace, name)
+		return true
+	}
+	cm, ok := o.(*apiv1.ConfigMap)
+	if !ok {
+		c.logger.Error("received unexpected object", "object", o)
+		return false
+	}
+
+	groups, err := c.parseGroups(cm)
+	if err != nil {
+		// A half-edited list must not wipe the targets already discovered,
+		// so the previous good parse stays in effect.
+		c.logger.Error("Invalid target list in ConfigMap, keeping previous targets", "namespace", namespace, "configmap", name, "key", c.key, "err", err)
+		return true
+	}
+	c.sendGroups(ctx, ch, groups, namespace, name)
+	return true
+}
+
+// parseGroups reads the target list from the configured data key. The list
+// uses the file-based discovery format; each group's source is its index in
+// the list.
+func (c *ConfigMap) parseGroups(cm *apiv1.ConfigMap) ([]*targetgroup.Group, error) {
+	data, ok := cm.Data[c.key]
+	if !ok {
+		return nil, fmt.Errorf("data key %q not found", c.key)
+	}
+	var groups []*targetgroup.Group
+	if err := yaml.Unmarshal([]byte(data), &groups); err != nil {
+		return nil, err
+	}
+	for i, tg := range groups {
+		if tg == nil {
+			return nil, errors.New("nil target group item")
+		}
+		tg.Source = configMapSource(cm.Namespace, cm.Name, i)
+		if tg.Labels == nil {
+			tg.Labels = model.LabelSet{}
+		}
+		tg.Labels[namespaceLabel] = lv(cm.Namespace)
+		tg.Labels[configMapNameLabel] = lv(cm.Name)
+	}
+	return groups, nil
+}
+
+// sendGroups emits the parsed groups plus empty groups for the sources a
+// previously longer list produced, so their targets are dropped.
+func (c *ConfigMap) sendGroups(ctx context.Context, ch chan<- []*targetgroup.Group, groups []*targetgroup.Group, namespace, name string) {
+	n := len(groups)
+	for i := n; i < c.lastLength; i++ {
+		groups = append(groups, &targetgroup.Group{Source: configMapSource(namespace, name, i)})
+	}
+	c.lastLength = n
+
+	select {
+	case <-ctx.Done():
+	case ch <- groups:
+	}
+}
+
+const configMapNameLabel = metaLabelPrefix + "configmap_name"
+
+func configMapSource(namespace, name string, i int) string {
+	return "configmap/" + namespace + "/" + name + "/" + strconv.Itoa(i)
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+func makeTargetsConfigMap(data string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "targets",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"targets.yml": data,
+		},
+	}
+}
+
+func makeConfigMapDiscovery() (*Discovery, kubernetes.Interface) {
+	n, c := makeDiscovery(RoleConfigMap, NamespaceDiscovery{})
+	n.configMapTargets = &ConfigMapTargetsConfig{
+		Namespace: "default",
+		Name:      "targets",
+		Key:       "targets.yml",
+	}
+	return n, c
+}
+
+const configMapTargetsData = `
+- targets: ['10.0.0.1:9100', '10.0.0.2:9100']
+  labels:
+    team: platform
+- targets: ['example.org:443']
+`
+
+func expectedConfigMapTargetGroups() map[string]*targetgroup.Group {
+	return map[string]*targetgroup.Group{
+		"configmap/default/targets/0": {
+			Targets: []model.LabelSet{
+				{
+					"__address__": "10.0.0.1:9100",
+				},
+				{
+					"__address__": "10.0.0.2:9100",
+				},
+			},
+			Labels: model.LabelSet{
+				"team":                             "platform",
+				"__meta_kubernetes_namespace":      "default",
+				"__meta_kubernetes_configmap_name": "targets",
+			},
+			Source: "configmap/default/targets/0",
+		},
+		"configmap/default/targets/1": {
+			Targets: []model.LabelSet{
+				{
+					"__address__": "example.org:443",
+				},
+			},
+			Labels: model.LabelSet{
+				"__meta_kubernetes_namespace":      "default",
+				"__meta_kubernetes_configmap_name": "targets",
+			},
+			Source: "configmap/default/targets/1",
+		},
+	}
+}
+
+func TestConfigMapDiscoveryAdd(t *testing.T) {
+	t.Parallel()
+	n, c := makeConfigMapDiscovery()
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeTargetsConfigMap(configMapTargetsData)
+			c.CoreV1().ConfigMaps(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes:      expectedConfigMapTargetGroups(),
+	}.Run(t)
+}
+
+func TestConfigMapDiscoveryShrink(t *testing.T) {
+	t.Parallel()
+	n, c := makeConfigMapDiscovery()
+	obj := makeTargetsConfigMap(configMapTargetsData)
+	c.CoreV1().ConfigMaps(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+
+	expected := expectedConfigMapTargetGroups()
+	expected["configmap/default/targets/1"] = &targetgroup.Group{
+		Source: "configmap/default/targets/1",
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeTargetsConfigMap("- targets: ['10.0.0.1:9100', '10.0.0.2:9100']\n  labels:\n    team: platform\n")
+			c.CoreV1().ConfigMaps(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
+		},
+		// Both the initial and the post-update batch cover the same two
+		// sources, so rely on the read timeout to merge them.
+		expectedMaxItems: 3,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestConfigMapDiscoveryInvalidUpdateKeepsTargets(t *testing.T) {
+	t.Parallel()
+	n, c := makeConfigMapDiscovery()
+	obj := makeTargetsConfigMap(configMapTargetsData)
+	c.CoreV1().ConfigMaps(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeTargetsConfigMap("- targets: [invalid\n")
+			c.CoreV1().ConfigMaps(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes:      expectedConfigMapTargetGroups(),
+	}.Run(t)
+}
+
+func TestConfigMapDiscoveryDelete(t *testing.T) {
+	t.Parallel()
+	n, c := makeConfigMapDiscovery()
+	obj := makeTargetsConfigMap(configMapTargetsData)
+	c.CoreV1().ConfigMaps(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			c.CoreV1().ConfigMaps("default").Delete(context.Background(), "targets", metav1.DeleteOptions{})
+		},
+		// Both the initial and the post-delete batch cover the same two
+		// sources, so rely on the read timeout to merge them.
+		expectedMaxItems: 3,
+		expectedRes: map[string]*targetgroup.Group{
+			"configmap/default/targets/0": {
+				Source: "configmap/default/targets/0",
+			},
+			"configmap/default/targets/1": {
+				Source: "configmap/default/targets/1",
+			},
+		},
+	}.Run(t)
+}
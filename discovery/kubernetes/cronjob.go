@@ -0,0 +1,235 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// CronJob implements discovery of Kubernetes CronJobs.
+type CronJob struct {
+	logger                 *slog.Logger
+	informer               cache.SharedIndexInformer
+	store                  cache.Store
+	queue                  *workqueue.Type
+	namespaceInf           cache.SharedInformer
+	withNamespaceMetadata  bool
+	namespaceLabelPrefixes []string
+}
+
+// NewCronJob returns a new cronjob discovery.
+func NewCronJob(l *slog.Logger, inf cache.SharedIndexInformer, namespace cache.SharedInformer, eventCount *prometheus.CounterVec, namespaceLabelPrefixes []string) *CronJob {
+	if l == nil {
+		l = promslog.NewNopLogger()
+	}
+
+	cronJobAddCount := eventCount.WithLabelValues(RoleCronJob.String(), MetricLabelRoleAdd)
+	cronJobUpdateCount := eventCount.WithLabelValues(RoleCronJob.String(), MetricLabelRoleUpdate)
+	cronJobDeleteCount := eventCount.WithLabelValues(RoleCronJob.String(), MetricLabelRoleDelete)
+
+	c := &CronJob{
+		logger:                 l,
+		informer:               inf,
+		store:                  inf.GetStore(),
+		queue:                  workqueue.NewNamed(RoleCronJob.String()),
+		namespaceInf:           namespace,
+		withNamespaceMetadata:  namespace != nil,
+		namespaceLabelPrefixes: namespaceLabelPrefixes,
+	}
+
+	_, err := c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(o interface{}) {
+			cronJobAddCount.Inc()
+			c.enqueue(o)
+		},
+		DeleteFunc: func(o interface{}) {
+			cronJobDeleteCount.Inc()
+			c.enqueue(o)
+		},
+		UpdateFunc: func(_, o interface{}) {
+			cronJobUpdateCount.Inc()
+			c.enqueue(o)
+		},
+	})
+	if err != nil {
+		l.Error("Error adding cronjobs event handler.", "err", err)
+	}
+
+	if c.withNamespaceMetadata {
+		_, err = c.namespaceInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, o interface{}) {
+				namespace := o.(*apiv1.Namespace)
+				c.enqueueNamespace(namespace.Name)
+			},
+			// Creation and deletion will trigger events for the change handlers of the resources within the namespace.
+			// No need to have additional handlers for them here.
+		})
+		if err != nil {
+			l.Error("Error adding namespaces event handler.", "err", err)
+		}
+	}
+
+	return c
+}
+
+func (c *CronJob) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+
+	c.queue.Add(key)
+}
+
+func (c *CronJob) enqueueNamespace(namespace string) {
+	cronJobs, err := c.informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		c.logger.Error("Error getting cronjobs in namespace", "namespace", namespace, "err", err)
+		return
+	}
+
+	for _, cronJob := range cronJobs {
+		c.enqueue(cronJob)
+	}
+}
+
+// Run implements the Discoverer interface.
+func (c *CronJob) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
+	defer c.queue.ShutDown()
+
+	cacheSyncs := []cache.InformerSynced{c.informer.HasSynced}
+	if c.withNamespaceMetadata {
+		cacheSyncs = append(cacheSyncs, c.namespaceInf.HasSynced)
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			c.logger.Error("cronjob informer unable to sync cache")
+		}
+		return
+	}
+
+	go func() {
+		for c.process(ctx, ch) {
+		}
+	}()
+
+	// Block until the target provider is explicitly canceled.
+	<-ctx.Done()
+}
+
+func (c *CronJob) process(ctx context.Context, ch chan<- []*targetgroup.Group) bool {
+	keyObj, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(keyObj)
+	key := keyObj.(string)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return true
+	}
+
+	o, exists, err := c.store.GetByKey(key)
+	if err != nil {
+		return true
+	}
+	if !exists {
+		send(ctx, ch, &targetgroup.Group{Source: cronJobSourceFromNamespaceAndName(namespace, name)})
+		return true
+	}
+	cronJob, err := convertToCronJob(o)
+	if err != nil {
+		c.logger.Error("converting to CronJob object failed", "err", err)
+		return true
+	}
+	send(ctx, ch, c.buildCronJob(cronJob))
+	return true
+}
+
+func convertToCronJob(o interface{}) (*batchv1.CronJob, error) {
+	cronJob, ok := o.(*batchv1.CronJob)
+	if ok {
+		return cronJob, nil
+	}
+	return nil, fmt.Errorf("received unexpected object: %v", o)
+}
+
+func cronJobSource(c *batchv1.CronJob) string {
+	return cronJobSourceFromNamespaceAndName(c.Namespace, c.Name)
+}
+
+func cronJobSourceFromNamespaceAndName(namespace, name string) string {
+	return "cronjob/" + namespace + "/" + name
+}
+
+const (
+	cronJobScheduleLabel         = metaLabelPrefix + "cronjob_schedule"
+	cronJobSuspendLabel          = metaLabelPrefix + "cronjob_suspend"
+	cronJobLastScheduleTimeLabel = metaLabelPrefix + "cronjob_last_schedule_time"
+)
+
+func cronJobLabels(c *batchv1.CronJob) model.LabelSet {
+	ls := make(model.LabelSet)
+	ls[namespaceLabel] = lv(c.Namespace)
+	addObjectMetaLabels(ls, c.ObjectMeta, RoleCronJob)
+
+	return ls
+}
+
+func (c *CronJob) buildCronJob(cronJob *batchv1.CronJob) *targetgroup.Group {
+	tg := &targetgroup.Group{
+		Source: cronJobSource(cronJob),
+	}
+	tg.Labels = cronJobLabels(cronJob)
+
+	if c.withNamespaceMetadata {
+		tg.Labels = addNamespaceLabels(tg.Labels, c.namespaceInf, c.logger, cronJob.Namespace, c.namespaceLabelPrefixes)
+	}
+
+	// A CronJob has no network address of its own; the name is used as the
+	// address so that relabeling can construct one if needed.
+	labelSet := model.LabelSet{
+		model.AddressLabel:   lv(cronJob.Name),
+		cronJobScheduleLabel: lv(cronJob.Spec.Schedule),
+	}
+
+	if cronJob.Spec.Suspend != nil {
+		labelSet[cronJobSuspendLabel] = lv(strconv.FormatBool(*cronJob.Spec.Suspend))
+	}
+
+	if cronJob.Status.LastScheduleTime != nil {
+		labelSet[cronJobLastScheduleTimeLabel] = lv(cronJob.Status.LastScheduleTime.Format(time.RFC3339))
+	}
+
+	tg.Targets = append(tg.Targets, labelSet)
+
+	return tg
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+func makeCronJob(namespace string) *batchv1.CronJob {
+	suspend := false
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testcronjob",
+			Namespace: namespace,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "*/5 * * * *",
+			Suspend:  &suspend,
+		},
+		Status: batchv1.CronJobStatus{
+			LastScheduleTime: &metav1.Time{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+	}
+}
+
+func TestCronJobDiscoveryAdd(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleCronJob, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeCronJob("default")
+			c.BatchV1().CronJobs(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"cronjob/default/testcronjob": {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                                  "testcronjob",
+						"__meta_kubernetes_cronjob_schedule":           "*/5 * * * *",
+						"__meta_kubernetes_cronjob_suspend":            "false",
+						"__meta_kubernetes_cronjob_last_schedule_time": "2024-01-02T03:04:05Z",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_cronjob_name": "testcronjob",
+					"__meta_kubernetes_namespace":    "default",
+				},
+				Source: "cronjob/default/testcronjob",
+			},
+		},
+	}.Run(t)
+}
+
+func TestCronJobDiscoveryDelete(t *testing.T) {
+	t.Parallel()
+	obj := makeCronJob("default")
+	n, c := makeDiscovery(RoleCronJob, NamespaceDiscovery{}, obj)
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			c.BatchV1().CronJobs(obj.Namespace).Delete(context.Background(), obj.Name, metav1.DeleteOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"cronjob/default/testcronjob": {
+				Source: "cronjob/default/testcronjob",
+			},
+		},
+	}.Run(t)
+}
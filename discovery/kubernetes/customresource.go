@@ -0,0 +1,214 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// CustomResource implements discovery of instances of an arbitrary custom
+// resource type, with the target address extracted via a JSONPath expression.
+type CustomResource struct {
+	logger      *slog.Logger
+	informer    cache.SharedInformer
+	store       cache.Store
+	queue       *workqueue.Type
+	addressPath *jsonpath.JSONPath
+}
+
+// parseAddressPath parses a JSONPath expression pointing at the target address
+// within a custom resource, e.g. {.spec.address}. Missing keys are allowed so
+// that objects without an address simply produce no target.
+func parseAddressPath(path string) (*jsonpath.JSONPath, error) {
+	if path == "" {
+		return nil, errors.New("address path must not be empty")
+	}
+	jp := jsonpath.New("address").AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
+
+// NewCustomResource returns a new custom resource discovery.
+func NewCustomResource(l *slog.Logger, inf cache.SharedInformer, addressPath string, eventCount *prometheus.CounterVec) *CustomResource {
+	if l == nil {
+		l = promslog.NewNopLogger()
+	}
+
+	jp, err := parseAddressPath(addressPath)
+	if err != nil {
+		// The address path is validated at configuration load time.
+		l.Error("Invalid custom resource address path.", "path", addressPath, "err", err)
+	}
+
+	crAddCount := eventCount.WithLabelValues(RoleCustomResource.String(), MetricLabelRoleAdd)
+	crUpdateCount := eventCount.WithLabelValues(RoleCustomResource.String(), MetricLabelRoleUpdate)
+	crDeleteCount := eventCount.WithLabelValues(RoleCustomResource.String(), MetricLabelRoleDelete)
+
+	c := &CustomResource{
+		logger:      l,
+		informer:    inf,
+		store:       inf.GetStore(),
+		queue:       workqueue.NewNamed(RoleCustomResource.String()),
+		addressPath: jp,
+	}
+
+	_, err = c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(o interface{}) {
+			crAddCount.Inc()
+			c.enqueue(o)
+		},
+		DeleteFunc: func(o interface{}) {
+			crDeleteCount.Inc()
+			c.enqueue(o)
+		},
+		UpdateFunc: func(_, o interface{}) {
+			crUpdateCount.Inc()
+			c.enqueue(o)
+		},
+	})
+	if err != nil {
+		l.Error("Error adding custom resources event handler.", "err", err)
+	}
+
+	return c
+}
+
+func (c *CustomResource) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+
+	c.queue.Add(key)
+}
+
+// Run implements the Discoverer interface.
+func (c *CustomResource) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
+	defer c.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			c.logger.Error("custom resource informer unable to sync cache")
+		}
+		return
+	}
+
+	go func() {
+		for c.process(ctx, ch) {
+		}
+	}()
+
+	// Block until the target provider is explicitly canceled.
+	<-ctx.Done()
+}
+
+func (c *CustomResource) process(ctx context.Context, ch chan<- []*targetgroup.Group) bool {
+	keyObj, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(keyObj)
+	key := keyObj.(string)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return true
+	}
+
+	o, exists, err := c.store.GetByKey(key)
+	if err != nil {
+		return true
+	}
+	if !exists {
+		send(ctx, ch, &targetgroup.Group{Source: customResourceSourceFromNamespaceAndName(namespace, name)})
+		return true
+	}
+	obj, err := convertToCustomResource(o)
+	if err != nil {
+		c.logger.Error("converting to custom resource object failed", "err", err)
+		return true
+	}
+	send(ctx, ch, c.buildCustomResource(obj))
+	return true
+}
+
+func convertToCustomResource(o interface{}) (*unstructured.Unstructured, error) {
+	obj, ok := o.(*unstructured.Unstructured)
+	if ok {
+		return obj, nil
+	}
+	return nil, fmt.Errorf("received unexpected object: %v", o)
+}
+
+func customResourceSource(o *unstructured.Unstructured) string {
+	return customResourceSourceFromNamespaceAndName(o.GetNamespace(), o.GetName())
+}
+
+func customResourceSourceFromNamespaceAndName(namespace, name string) string {
+	return "customresource/" + namespace + "/" + name
+}
+
+const customResourceNameLabel = metaLabelPrefix + "customresource_name"
+
+func (c *CustomResource) buildCustomResource(o *unstructured.Unstructured) *targetgroup.Group {
+	tg := &targetgroup.Group{
+		Source: customResourceSource(o),
+	}
+	tg.Labels = model.LabelSet{
+		customResourceNameLabel: lv(o.GetName()),
+		namespaceLabel:          lv(o.GetNamespace()),
+	}
+
+	addr, err := c.extractAddress(o)
+	if err != nil {
+		c.logger.Error("extracting address from custom resource failed", "namespace", o.GetNamespace(), "name", o.GetName(), "err", err)
+		return tg
+	}
+	// Objects without a resolvable address produce no target.
+	if addr == "" {
+		return tg
+	}
+
+	tg.Targets = append(tg.Targets, model.LabelSet{
+		model.AddressLabel: lv(addr),
+	})
+	return tg
+}
+
+func (c *CustomResource) extractAddress(o *unstructured.Unstructured) (string, error) {
+	if c.addressPath == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := c.addressPath.Execute(&buf, o.UnstructuredContent()); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
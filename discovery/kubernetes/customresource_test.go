@@ -0,0 +1,116 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+func makeCustomResource(name, address string) *unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	if address != "" {
+		spec["address"] = address
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "ExternalService",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		"spec":       spec,
+	}}
+}
+
+func makeCustomResourceDiscovery() (*Discovery, dynamic.Interface) {
+	n, _ := makeDiscovery(RoleCustomResource, NamespaceDiscovery{})
+	n.customResource = &CustomResourceConfig{
+		Group:       "example.com",
+		Version:     "v1",
+		Resource:    "externalservices",
+		AddressPath: "{.spec.address}",
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{n.customResource.groupVersionResource(): "ExternalServiceList"},
+	)
+	n.dynamicClient = dyn
+	return n, dyn
+}
+
+func TestCustomResourceDiscoveryAdd(t *testing.T) {
+	t.Parallel()
+	n, dyn := makeCustomResourceDiscovery()
+	res := dyn.Resource(n.customResource.groupVersionResource()).Namespace("default")
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeCustomResource("testcr", "1.2.3.4:8080")
+			res.Create(context.Background(), obj, metav1.CreateOptions{})
+			obj = makeCustomResource("noaddress", "")
+			res.Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"customresource/default/testcr": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:8080",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_customresource_name": "testcr",
+					"__meta_kubernetes_namespace":           "default",
+				},
+				Source: "customresource/default/testcr",
+			},
+			"customresource/default/noaddress": {
+				Labels: model.LabelSet{
+					"__meta_kubernetes_customresource_name": "noaddress",
+					"__meta_kubernetes_namespace":           "default",
+				},
+				Source: "customresource/default/noaddress",
+			},
+		},
+	}.Run(t)
+}
+
+func TestCustomResourceDiscoveryDelete(t *testing.T) {
+	t.Parallel()
+	n, dyn := makeCustomResourceDiscovery()
+	res := dyn.Resource(n.customResource.groupVersionResource()).Namespace("default")
+	res.Create(context.Background(), makeCustomResource("testcr", "1.2.3.4:8080"), metav1.CreateOptions{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			res.Delete(context.Background(), "testcr", metav1.DeleteOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"customresource/default/testcr": {
+				Source: "customresource/default/testcr",
+			},
+		},
+	}.Run(t)
+}
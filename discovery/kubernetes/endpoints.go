@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"regexp"
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -35,13 +36,18 @@ import (
 type Endpoints struct {
 	logger *slog.Logger
 
-	endpointsInf          cache.SharedIndexInformer
-	serviceInf            cache.SharedInformer
-	podInf                cache.SharedInformer
-	nodeInf               cache.SharedInformer
-	withNodeMetadata      bool
-	namespaceInf          cache.SharedInformer
-	withNamespaceMetadata bool
+	endpointsInf           cache.SharedIndexInformer
+	serviceInf             cache.SharedInformer
+	podInf                 cache.SharedInformer
+	nodeInf                cache.SharedInformer
+	withNodeMetadata       bool
+	namespaceInf           cache.SharedInformer
+	withNamespaceMetadata  bool
+	replicaSetInf          cache.SharedInformer
+	withOwnerWorkload      bool
+	excludeNodeTaints      []string
+	namespaceLabelPrefixes []string
+	portNameRe             *regexp.Regexp
 
 	podStore       cache.Store
 	endpointsStore cache.Store
@@ -52,7 +58,7 @@ type Endpoints struct {
 
 // NewEndpoints returns a new endpoints discovery.
 // Endpoints API is deprecated in k8s v1.33+, but we should still support it.
-func NewEndpoints(l *slog.Logger, eps cache.SharedIndexInformer, svc, pod, node, namespace cache.SharedInformer, eventCount *prometheus.CounterVec) *Endpoints {
+func NewEndpoints(l *slog.Logger, eps cache.SharedIndexInformer, svc, pod, node, namespace, replicaSet cache.SharedInformer, eventCount *prometheus.CounterVec, excludeNodeTaints, namespaceLabelPrefixes []string, portNameRe *regexp.Regexp) *Endpoints {
 	if l == nil {
 		l = promslog.NewNopLogger()
 	}
@@ -68,18 +74,23 @@ func NewEndpoints(l *slog.Logger, eps cache.SharedIndexInformer, svc, pod, node,
 	podUpdateCount := eventCount.WithLabelValues(RolePod.String(), MetricLabelRoleUpdate)
 
 	e := &Endpoints{
-		logger:                l,
-		endpointsInf:          eps,
-		endpointsStore:        eps.GetStore(),
-		serviceInf:            svc,
-		serviceStore:          svc.GetStore(),
-		podInf:                pod,
-		podStore:              pod.GetStore(),
-		nodeInf:               node,
-		withNodeMetadata:      node != nil,
-		namespaceInf:          namespace,
-		withNamespaceMetadata: namespace != nil,
-		queue:                 workqueue.NewNamed(RoleEndpoint.String()),
+		logger:                 l,
+		endpointsInf:           eps,
+		endpointsStore:         eps.GetStore(),
+		serviceInf:             svc,
+		serviceStore:           svc.GetStore(),
+		podInf:                 pod,
+		podStore:               pod.GetStore(),
+		nodeInf:                node,
+		withNodeMetadata:       node != nil,
+		namespaceInf:           namespace,
+		withNamespaceMetadata:  namespace != nil,
+		replicaSetInf:          replicaSet,
+		withOwnerWorkload:      replicaSet != nil,
+		excludeNodeTaints:      excludeNodeTaints,
+		namespaceLabelPrefixes: namespaceLabelPrefixes,
+		portNameRe:             portNameRe,
+		queue:                  workqueue.NewNamed(RoleEndpoint.String()),
 	}
 
 	_, err := e.endpointsInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -254,6 +265,9 @@ func (e *Endpoints) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
 	if e.withNamespaceMetadata {
 		cacheSyncs = append(cacheSyncs, e.namespaceInf.HasSynced)
 	}
+	if e.withOwnerWorkload {
+		cacheSyncs = append(cacheSyncs, e.replicaSetInf.HasSynced)
+	}
 
 	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
 		if !errors.Is(ctx.Err(), context.Canceled) {
@@ -323,6 +337,7 @@ func endpointsSourceFromNamespaceAndName(namespace, name string) string {
 const (
 	endpointNodeName               = metaLabelPrefix + "endpoint_node_name"
 	endpointHostname               = metaLabelPrefix + "endpoint_hostname"
+	endpointAddressIPLabel         = metaLabelPrefix + "endpoint_address_ip"
 	endpointReadyLabel             = metaLabelPrefix + "endpoint_ready"
 	endpointPortNameLabel          = metaLabelPrefix + "endpoint_port_name"
 	endpointPortProtocolLabel      = metaLabelPrefix + "endpoint_port_protocol"
@@ -342,7 +357,7 @@ func (e *Endpoints) buildEndpoints(eps *apiv1.Endpoints) *targetgroup.Group {
 	addObjectMetaLabels(tg.Labels, eps.ObjectMeta, RoleEndpoint)
 
 	if e.withNamespaceMetadata {
-		tg.Labels = addNamespaceLabels(tg.Labels, e.namespaceInf, e.logger, eps.Namespace)
+		tg.Labels = addNamespaceLabels(tg.Labels, e.namespaceInf, e.logger, eps.Namespace, e.namespaceLabelPrefixes)
 	}
 
 	type podEntry struct {
@@ -356,6 +371,7 @@ func (e *Endpoints) buildEndpoints(eps *apiv1.Endpoints) *targetgroup.Group {
 
 		target := model.LabelSet{
 			model.AddressLabel:        lv(a),
+			endpointAddressIPLabel:    lv(addr.IP),
 			endpointPortNameLabel:     lv(port.Name),
 			endpointPortProtocolLabel: lv(string(port.Protocol)),
 			endpointReadyLabel:        lv(ready),
@@ -375,8 +391,15 @@ func (e *Endpoints) buildEndpoints(eps *apiv1.Endpoints) *targetgroup.Group {
 
 		if e.withNodeMetadata {
 			if addr.NodeName != nil {
+				if nodeExcludedByTaint(e.nodeInf, *addr.NodeName, e.excludeNodeTaints) {
+					// Scheduled onto an excluded node, drop the target.
+					return
+				}
 				target = addNodeLabels(target, e.nodeInf, e.logger, addr.NodeName)
 			} else if addr.TargetRef != nil && addr.TargetRef.Kind == "Node" {
+				if nodeExcludedByTaint(e.nodeInf, addr.TargetRef.Name, e.excludeNodeTaints) {
+					return
+				}
 				target = addNodeLabels(target, e.nodeInf, e.logger, &addr.TargetRef.Name)
 			}
 		}
@@ -397,6 +420,10 @@ func (e *Endpoints) buildEndpoints(eps *apiv1.Endpoints) *targetgroup.Group {
 
 		// Attach standard pod labels.
 		target = target.Merge(podLabels(pod))
+		addStatefulSetLabels(target, pod)
+		if e.withOwnerWorkload {
+			addOwnerWorkloadLabels(target, pod, e.replicaSetInf, e.logger)
+		}
 
 		// Attach potential container port labels matching the endpoint port.
 		containers := append(pod.Spec.Containers, pod.Spec.InitContainers...)
@@ -425,6 +452,11 @@ func (e *Endpoints) buildEndpoints(eps *apiv1.Endpoints) *targetgroup.Group {
 
 	for _, ss := range eps.Subsets {
 		for _, port := range ss.Ports {
+			// Port names cannot be filtered server-side, so the name filter
+			// is applied here.
+			if e.portNameRe != nil && !e.portNameRe.MatchString(port.Name) {
+				continue
+			}
 			for _, addr := range ss.Addresses {
 				add(addr, port, "true")
 			}
@@ -466,6 +498,11 @@ func (e *Endpoints) buildEndpoints(eps *apiv1.Endpoints) *targetgroup.Group {
 				if hasSeenPort() {
 					continue
 				}
+				// The name filter applies to these extra targets as well, by
+				// the container port name as they have no endpoint port.
+				if e.portNameRe != nil && !e.portNameRe.MatchString(cport.Name) {
+					continue
+				}
 
 				a := net.JoinHostPort(pe.pod.Status.PodIP, strconv.FormatUint(uint64(cport.ContainerPort), 10))
 				ports := strconv.FormatUint(uint64(cport.ContainerPort), 10)
@@ -480,7 +517,12 @@ func (e *Endpoints) buildEndpoints(eps *apiv1.Endpoints) *targetgroup.Group {
 					podContainerPortProtocolLabel: lv(string(cport.Protocol)),
 					podContainerIsInit:            lv(strconv.FormatBool(isInit)),
 				}
-				tg.Targets = append(tg.Targets, target.Merge(podLabels(pe.pod)))
+				target = target.Merge(podLabels(pe.pod))
+				addStatefulSetLabels(target, pe.pod)
+				if e.withOwnerWorkload {
+					addOwnerWorkloadLabels(target, pe.pod, e.replicaSetInf, e.logger)
+				}
+				tg.Targets = append(tg.Targets, target)
 			}
 		}
 	}
@@ -537,10 +579,11 @@ func addNodeLabels(tg model.LabelSet, nodeInf cache.SharedInformer, logger *slog
 	// Allocate one target label for the node name,
 	nodeLabelset := make(model.LabelSet)
 	addObjectMetaLabels(nodeLabelset, node.ObjectMeta, RoleNode)
+	nodeLabelset[nodeUnschedulableLabel] = lv(strconv.FormatBool(node.Spec.Unschedulable))
 	return tg.Merge(nodeLabelset)
 }
 
-func addNamespaceLabels(tg model.LabelSet, namespaceInf cache.SharedInformer, logger *slog.Logger, namespace string) model.LabelSet {
+func addNamespaceLabels(tg model.LabelSet, namespaceInf cache.SharedInformer, logger *slog.Logger, namespace string, labelPrefixes []string) model.LabelSet {
 	obj, exists, err := namespaceInf.GetStore().GetByKey(namespace)
 	if err != nil {
 		logger.Error("Error getting namespace", "namespace", namespace, "err", err)
@@ -553,6 +596,6 @@ func addNamespaceLabels(tg model.LabelSet, namespaceInf cache.SharedInformer, lo
 
 	n := obj.(*apiv1.Namespace)
 	namespaceLabelset := make(model.LabelSet)
-	addNamespaceMetaLabels(namespaceLabelset, n.ObjectMeta)
+	addNamespaceMetaLabels(namespaceLabelset, n.ObjectMeta, labelPrefixes)
 	return tg.Merge(namespaceLabelset)
 }
@@ -16,6 +16,7 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/prometheus/common/model"
@@ -113,6 +114,7 @@ func TestEndpointsDiscoveryBeforeRun(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -121,18 +123,21 @@ func TestEndpointsDiscoveryBeforeRun(t *testing.T) {
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -152,6 +157,66 @@ func TestEndpointsDiscoveryBeforeRun(t *testing.T) {
 	}.Run(t)
 }
 
+func TestEndpointsDiscoveryPortNamePattern(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleEndpoint, NamespaceDiscovery{})
+	n.endpointPortNameRe = regexp.MustCompile("^(?:metrics)$")
+
+	obj := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testendpoints",
+			Namespace: "default",
+		},
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: []v1.EndpointAddress{
+					{
+						IP: "1.2.3.4",
+					},
+				},
+				Ports: []v1.EndpointPort{
+					{
+						Name:     "metrics",
+						Port:     9090,
+						Protocol: v1.ProtocolTCP,
+					},
+					{
+						Name:     "web",
+						Port:     8080,
+						Protocol: v1.ProtocolTCP,
+					},
+				},
+			},
+		},
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			c.CoreV1().Endpoints(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpoints/default/testendpoints": {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                              "1.2.3.4:9090",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
+						"__meta_kubernetes_endpoint_port_name":     "metrics",
+						"__meta_kubernetes_endpoint_port_protocol": "TCP",
+						"__meta_kubernetes_endpoint_ready":         "true",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_namespace":      "default",
+					"__meta_kubernetes_endpoints_name": "testendpoints",
+				},
+				Source: "endpoints/default/testendpoints",
+			},
+		},
+	}.Run(t)
+}
+
 func TestEndpointsDiscoveryAdd(t *testing.T) {
 	t.Parallel()
 	obj := &v1.Pod{
@@ -232,6 +297,7 @@ func TestEndpointsDiscoveryAdd(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                                    "4.3.2.1:9000",
+						"__meta_kubernetes_endpoint_address_ip":          "4.3.2.1",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
 						"__meta_kubernetes_endpoint_port_protocol":       "TCP",
 						"__meta_kubernetes_endpoint_ready":               "true",
@@ -348,12 +414,14 @@ func TestEndpointsDiscoveryUpdate(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
@@ -398,6 +466,65 @@ func TestEndpointsDiscoveryEmptySubsets(t *testing.T) {
 	}.Run(t)
 }
 
+func TestEndpointsDiscoveryAPIServerRole(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleAPIServer, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kubernetes",
+					Namespace: "default",
+					Labels: map[string]string{
+						"component": "apiserver",
+						"provider":  "kubernetes",
+					},
+				},
+			}
+			eps := &v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kubernetes",
+					Namespace: "default",
+				},
+				Subsets: []v1.EndpointSubset{
+					{
+						Addresses: []v1.EndpointAddress{{IP: "192.168.0.1"}},
+						Ports:     []v1.EndpointPort{{Name: "https", Port: 6443, Protocol: v1.ProtocolTCP}},
+					},
+				},
+			}
+			c.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+			c.CoreV1().Endpoints(eps.Namespace).Create(context.Background(), eps, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpoints/default/kubernetes": {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                              "192.168.0.1:6443",
+						"__meta_kubernetes_endpoint_address_ip":    "192.168.0.1",
+						"__meta_kubernetes_endpoint_port_name":     "https",
+						"__meta_kubernetes_endpoint_port_protocol": "TCP",
+						"__meta_kubernetes_endpoint_ready":         "true",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_namespace":                      "default",
+					"__meta_kubernetes_endpoints_name":                 "kubernetes",
+					"__meta_kubernetes_service_name":                   "kubernetes",
+					"__meta_kubernetes_service_label_component":        "apiserver",
+					"__meta_kubernetes_service_labelpresent_component": "true",
+					"__meta_kubernetes_service_label_provider":         "kubernetes",
+					"__meta_kubernetes_service_labelpresent_provider":  "true",
+				},
+				Source: "endpoints/default/kubernetes",
+			},
+		},
+	}.Run(t)
+}
+
 func TestEndpointsDiscoveryWithService(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RoleEndpoint, NamespaceDiscovery{}, makeEndpoints("default"))
@@ -422,6 +549,7 @@ func TestEndpointsDiscoveryWithService(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -430,18 +558,21 @@ func TestEndpointsDiscoveryWithService(t *testing.T) {
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -501,6 +632,7 @@ func TestEndpointsDiscoveryWithServiceUpdate(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -509,18 +641,21 @@ func TestEndpointsDiscoveryWithServiceUpdate(t *testing.T) {
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -571,6 +706,7 @@ func TestEndpointsDiscoveryWithNodeMetadata(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -579,21 +715,25 @@ func TestEndpointsDiscoveryWithNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":          "us-east1",
 						"__meta_kubernetes_node_labelpresent_az":   "true",
 						"__meta_kubernetes_node_name":              "foobar",
+						"__meta_kubernetes_node_unschedulable":     "false",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -602,6 +742,7 @@ func TestEndpointsDiscoveryWithNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":                "us-west2",
 						"__meta_kubernetes_node_labelpresent_az":         "true",
 						"__meta_kubernetes_node_name":                    "barbaz",
+						"__meta_kubernetes_node_unschedulable":           "false",
 					},
 				},
 				Labels: model.LabelSet{
@@ -649,6 +790,7 @@ func TestEndpointsDiscoveryWithUpdatedNodeMetadata(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -657,21 +799,25 @@ func TestEndpointsDiscoveryWithUpdatedNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":          "us-east1",
 						"__meta_kubernetes_node_labelpresent_az":   "true",
 						"__meta_kubernetes_node_name":              "foobar",
+						"__meta_kubernetes_node_unschedulable":     "false",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -680,6 +826,7 @@ func TestEndpointsDiscoveryWithUpdatedNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":                "us-west2",
 						"__meta_kubernetes_node_labelpresent_az":         "true",
 						"__meta_kubernetes_node_name":                    "barbaz",
+						"__meta_kubernetes_node_unschedulable":           "false",
 					},
 				},
 				Labels: model.LabelSet{
@@ -777,6 +924,7 @@ func TestEndpointsDiscoveryNamespaces(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -785,18 +933,21 @@ func TestEndpointsDiscoveryNamespaces(t *testing.T) {
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -819,6 +970,7 @@ func TestEndpointsDiscoveryNamespaces(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                                    "4.3.2.1:9000",
+						"__meta_kubernetes_endpoint_address_ip":          "4.3.2.1",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
 						"__meta_kubernetes_endpoint_port_protocol":       "TCP",
 						"__meta_kubernetes_endpoint_ready":               "true",
@@ -905,6 +1057,7 @@ func TestEndpointsDiscoveryOwnNamespace(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -913,18 +1066,21 @@ func TestEndpointsDiscoveryOwnNamespace(t *testing.T) {
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -1080,6 +1236,7 @@ func TestEndpointsDiscoveryUpdatePod(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                                    "4.3.2.1:9000",
+						"__meta_kubernetes_endpoint_address_ip":          "4.3.2.1",
 						"__meta_kubernetes_endpoint_port_name":           "mainport",
 						"__meta_kubernetes_endpoint_port_protocol":       "TCP",
 						"__meta_kubernetes_endpoint_ready":               "true",
@@ -1207,7 +1364,8 @@ func TestEndpointsDiscoverySidecarContainer(t *testing.T) {
 			"endpoints/default/testsidecar": {
 				Targets: []model.LabelSet{
 					{
-						"__address__": "4.3.2.1:9000",
+						"__address__":                                    "4.3.2.1:9000",
+						"__meta_kubernetes_endpoint_address_ip":          "4.3.2.1",
 						"__meta_kubernetes_endpoint_address_target_kind": "Pod",
 						"__meta_kubernetes_endpoint_address_target_name": "testpod",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -1228,7 +1386,8 @@ func TestEndpointsDiscoverySidecarContainer(t *testing.T) {
 						"__meta_kubernetes_pod_container_init":           "false",
 					},
 					{
-						"__address__": "4.3.2.1:9111",
+						"__address__":                                    "4.3.2.1:9111",
+						"__meta_kubernetes_endpoint_address_ip":          "4.3.2.1",
 						"__meta_kubernetes_endpoint_address_target_kind": "Pod",
 						"__meta_kubernetes_endpoint_address_target_name": "testpod",
 						"__meta_kubernetes_endpoint_port_name":           "initport",
@@ -1291,6 +1450,7 @@ func TestEndpointsDiscoveryWithNamespaceMetadata(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -1299,18 +1459,21 @@ func TestEndpointsDiscoveryWithNamespaceMetadata(t *testing.T) {
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
@@ -1363,6 +1526,7 @@ func TestEndpointsDiscoveryWithUpdatedNamespaceMetadata(t *testing.T) {
 				Targets: []model.LabelSet{
 					{
 						"__address__":                              "1.2.3.4:9000",
+						"__meta_kubernetes_endpoint_address_ip":    "1.2.3.4",
 						"__meta_kubernetes_endpoint_hostname":      "testendpoint1",
 						"__meta_kubernetes_endpoint_node_name":     "foobar",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
@@ -1371,18 +1535,21 @@ func TestEndpointsDiscoveryWithUpdatedNamespaceMetadata(t *testing.T) {
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "true",
 					},
 					{
 						"__address__":                              "2.3.4.5:9001",
+						"__meta_kubernetes_endpoint_address_ip":    "2.3.4.5",
 						"__meta_kubernetes_endpoint_port_name":     "testport",
 						"__meta_kubernetes_endpoint_port_protocol": "TCP",
 						"__meta_kubernetes_endpoint_ready":         "false",
 					},
 					{
-						"__address__": "6.7.8.9:9002",
+						"__address__":                                    "6.7.8.9:9002",
+						"__meta_kubernetes_endpoint_address_ip":          "6.7.8.9",
 						"__meta_kubernetes_endpoint_address_target_kind": "Node",
 						"__meta_kubernetes_endpoint_address_target_name": "barbaz",
 						"__meta_kubernetes_endpoint_port_name":           "testport",
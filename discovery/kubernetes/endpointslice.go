@@ -18,13 +18,17 @@ import (
 	"errors"
 	"log/slog"
 	"net"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/promslog"
 	apiv1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
@@ -34,17 +38,33 @@ import (
 
 const serviceIndex = "service"
 
+// Well-known labels the MCS controller stamps onto the EndpointSlices it
+// derives from a ServiceImport (multicluster.x-k8s.io).
+const (
+	mcsServiceNameLabel   = "multicluster.kubernetes.io/service-name"
+	mcsSourceClusterLabel = "multicluster.kubernetes.io/source-cluster"
+)
+
 // EndpointSlice discovers new endpoint targets.
 type EndpointSlice struct {
 	logger *slog.Logger
 
-	endpointSliceInf      cache.SharedIndexInformer
-	serviceInf            cache.SharedInformer
-	podInf                cache.SharedInformer
-	nodeInf               cache.SharedInformer
-	withNodeMetadata      bool
-	namespaceInf          cache.SharedInformer
-	withNamespaceMetadata bool
+	endpointSliceInf       cache.SharedIndexInformer
+	serviceInf             cache.SharedInformer
+	podInf                 cache.SharedInformer
+	nodeInf                cache.SharedInformer
+	withNodeMetadata       bool
+	namespaceInf           cache.SharedInformer
+	withNamespaceMetadata  bool
+	namespaceLabelPrefixes []string
+	replicaSetInf          cache.SharedInformer
+	withOwnerWorkload      bool
+	excludeNodeTaints      []string
+	appProtocols           []string
+	deduplicateEndpoints   bool
+	groupByService         bool
+	serviceImportInf       cache.SharedInformer
+	withServiceImport      bool
 
 	podStore           cache.Store
 	endpointSliceStore cache.Store
@@ -54,46 +74,65 @@ type EndpointSlice struct {
 }
 
 // NewEndpointSlice returns a new endpointslice discovery.
-func NewEndpointSlice(l *slog.Logger, eps cache.SharedIndexInformer, svc, pod, node, namespace cache.SharedInformer, eventCount *prometheus.CounterVec) *EndpointSlice {
+func NewEndpointSlice(l *slog.Logger, eps cache.SharedIndexInformer, svc, pod, node, namespace, replicaSet cache.SharedInformer, eventCount *prometheus.CounterVec, excludeNodeTaints, namespaceLabelPrefixes, appProtocols []string, deduplicateEndpoints, groupByService bool, serviceImport cache.SharedInformer) *EndpointSlice {
 	if l == nil {
 		l = promslog.NewNopLogger()
 	}
 
-	epslAddCount := eventCount.WithLabelValues(RoleEndpointSlice.String(), MetricLabelRoleAdd)
-	epslUpdateCount := eventCount.WithLabelValues(RoleEndpointSlice.String(), MetricLabelRoleUpdate)
-	epslDeleteCount := eventCount.WithLabelValues(RoleEndpointSlice.String(), MetricLabelRoleDelete)
+	// With a ServiceImport informer the discovery serves the serviceimport
+	// role, which keeps its own event counts and queue.
+	role := RoleEndpointSlice
+	if serviceImport != nil {
+		role = RoleServiceImport
+	}
+
+	epslAddCount := eventCount.WithLabelValues(role.String(), MetricLabelRoleAdd)
+	epslUpdateCount := eventCount.WithLabelValues(role.String(), MetricLabelRoleUpdate)
+	epslDeleteCount := eventCount.WithLabelValues(role.String(), MetricLabelRoleDelete)
 
 	svcAddCount := eventCount.WithLabelValues(RoleService.String(), MetricLabelRoleAdd)
 	svcUpdateCount := eventCount.WithLabelValues(RoleService.String(), MetricLabelRoleUpdate)
 	svcDeleteCount := eventCount.WithLabelValues(RoleService.String(), MetricLabelRoleDelete)
 
 	e := &EndpointSlice{
-		logger:                l,
-		endpointSliceInf:      eps,
-		endpointSliceStore:    eps.GetStore(),
-		serviceInf:            svc,
-		serviceStore:          svc.GetStore(),
-		podInf:                pod,
-		podStore:              pod.GetStore(),
-		nodeInf:               node,
-		withNodeMetadata:      node != nil,
-		namespaceInf:          namespace,
-		withNamespaceMetadata: namespace != nil,
-		queue:                 workqueue.NewNamed(RoleEndpointSlice.String()),
+		logger:                 l,
+		endpointSliceInf:       eps,
+		endpointSliceStore:     eps.GetStore(),
+		serviceInf:             svc,
+		serviceStore:           svc.GetStore(),
+		podInf:                 pod,
+		podStore:               pod.GetStore(),
+		nodeInf:                node,
+		withNodeMetadata:       node != nil,
+		namespaceInf:           namespace,
+		withNamespaceMetadata:  namespace != nil,
+		namespaceLabelPrefixes: namespaceLabelPrefixes,
+		replicaSetInf:          replicaSet,
+		withOwnerWorkload:      replicaSet != nil,
+		excludeNodeTaints:      excludeNodeTaints,
+		appProtocols:           appProtocols,
+		deduplicateEndpoints:   deduplicateEndpoints,
+		groupByService:         groupByService,
+		serviceImportInf:       serviceImport,
+		withServiceImport:      serviceImport != nil,
+		queue:                  workqueue.NewNamed(role.String()),
 	}
 
 	_, err := e.endpointSliceInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(o interface{}) {
 			epslAddCount.Inc()
 			e.enqueue(o)
+			e.enqueueSiblingSlices(o)
 		},
 		UpdateFunc: func(_, o interface{}) {
 			epslUpdateCount.Inc()
 			e.enqueue(o)
+			e.enqueueSiblingSlices(o)
 		},
 		DeleteFunc: func(o interface{}) {
 			epslDeleteCount.Inc()
 			e.enqueue(o)
+			e.enqueueSiblingSlices(o)
 		},
 	})
 	if err != nil {
@@ -172,9 +211,56 @@ func NewEndpointSlice(l *slog.Logger, eps cache.SharedIndexInformer, svc, pod, n
 		}
 	}
 
+	if e.withServiceImport {
+		_, err = e.serviceImportInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    e.enqueueSlicesForServiceImport,
+			UpdateFunc: func(_, o interface{}) { e.enqueueSlicesForServiceImport(o) },
+			DeleteFunc: e.enqueueSlicesForServiceImport,
+		})
+		if err != nil {
+			l.Error("Error adding service imports event handler.", "err", err)
+		}
+	}
+
 	return e
 }
 
+// enqueueSlicesForServiceImport re-enqueues the derived slices of a changed
+// ServiceImport, so that their targets appear or vanish with the import.
+func (e *EndpointSlice) enqueueSlicesForServiceImport(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	for _, o := range e.endpointSliceStore.List() {
+		eps, ok := o.(*v1.EndpointSlice)
+		if !ok || eps.Namespace != acc.GetNamespace() {
+			continue
+		}
+		if eps.Labels[mcsServiceNameLabel] == acc.GetName() {
+			e.enqueue(eps)
+		}
+	}
+}
+
+// serviceImported reports whether the ServiceImport the slice was derived
+// from still exists.
+func (e *EndpointSlice) serviceImported(eps *v1.EndpointSlice) bool {
+	name, found := eps.Labels[mcsServiceNameLabel]
+	if !found {
+		return false
+	}
+	_, exists, err := e.serviceImportInf.GetStore().GetByKey(namespacedName(eps.Namespace, name))
+	if err != nil {
+		e.logger.Error("retrieving service import failed", "err", err)
+		return false
+	}
+	return exists
+}
+
 func (e *EndpointSlice) enqueueNode(nodeName string) {
 	endpoints, err := e.endpointSliceInf.GetIndexer().ByIndex(nodeIndex, nodeName)
 	if err != nil {
@@ -199,7 +285,47 @@ func (e *EndpointSlice) enqueueNamespace(namespace string) {
 	}
 }
 
+// enqueueSiblingSlices re-enqueues the other slices of the changed slice's
+// service so that, with deduplication enabled, they pick up or release
+// targets the changed slice now covers or no longer covers.
+func (e *EndpointSlice) enqueueSiblingSlices(obj interface{}) {
+	if !e.deduplicateEndpoints {
+		return
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	eps, ok := obj.(*v1.EndpointSlice)
+	if !ok {
+		return
+	}
+	svcName, found := eps.Labels[v1.LabelServiceName]
+	if !found {
+		return
+	}
+
+	siblings, err := e.endpointSliceInf.GetIndexer().ByIndex(serviceIndex, namespacedName(eps.Namespace, svcName))
+	if err != nil {
+		e.logger.Error("getting endpoint slices by service name failed", "err", err)
+		return
+	}
+	for _, sibling := range siblings {
+		if s, ok := sibling.(*v1.EndpointSlice); ok && s.Name == eps.Name {
+			continue
+		}
+		e.enqueue(sibling)
+	}
+}
+
 func (e *EndpointSlice) enqueue(obj interface{}) {
+	// With service grouping, all slices of a service share one queue key so
+	// that any slice event recomputes the whole merged group.
+	if e.groupByService {
+		if key, ok := serviceGroupKey(obj); ok {
+			e.queue.Add(key)
+			return
+		}
+	}
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		return
@@ -208,6 +334,24 @@ func (e *EndpointSlice) enqueue(obj interface{}) {
 	e.queue.Add(key)
 }
 
+// serviceGroupKey returns the queue key of the merged group an endpoint slice
+// belongs to, i.e. the namespaced name of its service. Slices without a
+// service label keep their own per-slice key.
+func serviceGroupKey(obj interface{}) (string, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	eps, ok := obj.(*v1.EndpointSlice)
+	if !ok {
+		return "", false
+	}
+	svcName, found := eps.Labels[v1.LabelServiceName]
+	if !found {
+		return "", false
+	}
+	return namespacedName(eps.Namespace, svcName), true
+}
+
 // Run implements the Discoverer interface.
 func (e *EndpointSlice) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
 	defer e.queue.ShutDown()
@@ -219,6 +363,12 @@ func (e *EndpointSlice) Run(ctx context.Context, ch chan<- []*targetgroup.Group)
 	if e.withNamespaceMetadata {
 		cacheSyncs = append(cacheSyncs, e.namespaceInf.HasSynced)
 	}
+	if e.withOwnerWorkload {
+		cacheSyncs = append(cacheSyncs, e.replicaSetInf.HasSynced)
+	}
+	if e.withServiceImport {
+		cacheSyncs = append(cacheSyncs, e.serviceImportInf.HasSynced)
+	}
 	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
 		if !errors.Is(ctx.Err(), context.Canceled) {
 			e.logger.Error("endpointslice informer unable to sync cache")
@@ -249,17 +399,39 @@ func (e *EndpointSlice) process(ctx context.Context, ch chan<- []*targetgroup.Gr
 		return true
 	}
 
+	if e.groupByService {
+		// The key names either a service or, for slices without a service
+		// label, a single slice; the index lookup tells them apart.
+		if sent, err := e.sendServiceGroup(ctx, ch, namespace, name); err != nil {
+			e.logger.Error("getting endpoint slices by service name failed", "err", err)
+			return true
+		} else if sent {
+			return true
+		}
+	}
+
 	o, exists, err := e.endpointSliceStore.GetByKey(key)
 	if err != nil {
 		e.logger.Error("getting object from store failed", "key", key)
 		return true
 	}
 	if !exists {
+		if e.groupByService {
+			// Neither a known service nor a live slice; clear both sources
+			// the key may have produced.
+			send(ctx, ch, &targetgroup.Group{Source: endpointSliceServiceSource(namespace, name)})
+		}
 		send(ctx, ch, &targetgroup.Group{Source: endpointSliceSourceFromNamespaceAndName(namespace, name)})
 		return true
 	}
 
 	if es, ok := o.(*v1.EndpointSlice); ok {
+		if e.withServiceImport && !e.serviceImported(es) {
+			// The import is gone or not yet created; the derived slice's
+			// targets must not be scraped.
+			send(ctx, ch, &targetgroup.Group{Source: endpointSliceSource(*es)})
+			return true
+		}
 		send(ctx, ch, e.buildEndpointSlice(*es))
 	} else {
 		e.logger.Error("received unexpected object", "object", o)
@@ -268,6 +440,46 @@ func (e *EndpointSlice) process(ctx context.Context, ch chan<- []*targetgroup.Gr
 	return true
 }
 
+// sendServiceGroup emits the merged target group of the named service, built
+// from all its live slices. It reports whether the key matched any slices;
+// when it did not, the caller falls back to per-slice handling.
+func (e *EndpointSlice) sendServiceGroup(ctx context.Context, ch chan<- []*targetgroup.Group, namespace, service string) (bool, error) {
+	objs, err := e.endpointSliceInf.GetIndexer().ByIndex(serviceIndex, namespacedName(namespace, service))
+	if err != nil {
+		return false, err
+	}
+	if len(objs) == 0 {
+		return false, nil
+	}
+
+	tg := &targetgroup.Group{
+		Source: endpointSliceServiceSource(namespace, service),
+		Labels: model.LabelSet{
+			namespaceLabel:                lv(namespace),
+			endpointSliceServiceNameLabel: lv(service),
+		},
+	}
+	slices := make([]*v1.EndpointSlice, 0, len(objs))
+	for _, o := range objs {
+		if es, ok := o.(*v1.EndpointSlice); ok {
+			slices = append(slices, es)
+		}
+	}
+	// Deterministic target order regardless of index iteration order.
+	sort.Slice(slices, func(i, j int) bool { return slices[i].Name < slices[j].Name })
+	for _, es := range slices {
+		sub := e.buildEndpointSlice(*es)
+		// Per-slice group labels like the slice name and address type differ
+		// between slices, so they move onto the targets; the scraped label
+		// sets are the same either way.
+		for _, target := range sub.Targets {
+			tg.Targets = append(tg.Targets, sub.Labels.Merge(target))
+		}
+	}
+	send(ctx, ch, tg)
+	return true, nil
+}
+
 func endpointSliceSource(ep v1.EndpointSlice) string {
 	return endpointSliceSourceFromNamespaceAndName(ep.Namespace, ep.Name)
 }
@@ -276,8 +488,16 @@ func endpointSliceSourceFromNamespaceAndName(namespace, name string) string {
 	return "endpointslice/" + namespace + "/" + name
 }
 
+// endpointSliceServiceSource is the source of the merged group of all slices
+// of a service, with group_by_service enabled. The extra segment keeps it
+// disjoint from per-slice sources, which slice names cannot contain.
+func endpointSliceServiceSource(namespace, service string) string {
+	return "endpointslice/" + namespace + "/service/" + service
+}
+
 const (
 	endpointSliceAddressTypeLabel                   = metaLabelPrefix + "endpointslice_address_type"
+	endpointSliceServiceNameLabel                   = metaLabelPrefix + "endpointslice_service_name"
 	endpointSlicePortNameLabel                      = metaLabelPrefix + "endpointslice_port_name"
 	endpointSlicePortProtocolLabel                  = metaLabelPrefix + "endpointslice_port_protocol"
 	endpointSlicePortLabel                          = metaLabelPrefix + "endpointslice_port"
@@ -285,13 +505,17 @@ const (
 	endpointSliceEndpointConditionsReadyLabel       = metaLabelPrefix + "endpointslice_endpoint_conditions_ready"
 	endpointSliceEndpointConditionsServingLabel     = metaLabelPrefix + "endpointslice_endpoint_conditions_serving"
 	endpointSliceEndpointConditionsTerminatingLabel = metaLabelPrefix + "endpointslice_endpoint_conditions_terminating"
+	endpointSliceEndpointReadySinceLabel            = metaLabelPrefix + "endpointslice_endpoint_ready_since"
 	endpointSliceEndpointZoneLabel                  = metaLabelPrefix + "endpointslice_endpoint_zone"
+	endpointSliceEndpointHintsForZonesLabel         = metaLabelPrefix + "endpointslice_endpoint_hints_for_zones"
 	endpointSliceEndpointHostnameLabel              = metaLabelPrefix + "endpointslice_endpoint_hostname"
 	endpointSliceEndpointNodenameLabel              = metaLabelPrefix + "endpointslice_endpoint_node_name"
 	endpointSliceAddressTargetKindLabel             = metaLabelPrefix + "endpointslice_address_target_kind"
 	endpointSliceAddressTargetNameLabel             = metaLabelPrefix + "endpointslice_address_target_name"
 	endpointSliceEndpointTopologyLabelPrefix        = metaLabelPrefix + "endpointslice_endpoint_topology_"
 	endpointSliceEndpointTopologyLabelPresentPrefix = metaLabelPrefix + "endpointslice_endpoint_topology_present_"
+
+	mcsClusterLabel = metaLabelPrefix + "mcs_cluster"
 )
 
 func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Group {
@@ -307,8 +531,14 @@ func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Gr
 
 	e.addServiceLabels(eps, tg)
 
+	if e.withServiceImport {
+		if cluster, found := eps.Labels[mcsSourceClusterLabel]; found {
+			tg.Labels[mcsClusterLabel] = lv(cluster)
+		}
+	}
+
 	if e.withNamespaceMetadata {
-		tg.Labels = addNamespaceLabels(tg.Labels, e.namespaceInf, e.logger, eps.Namespace)
+		tg.Labels = addNamespaceLabels(tg.Labels, e.namespaceInf, e.logger, eps.Namespace, e.namespaceLabelPrefixes)
 	}
 
 	type podEntry struct {
@@ -317,7 +547,17 @@ func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Gr
 	}
 	seenPods := map[string]*podEntry{}
 
+	duplicates := e.duplicateAddresses(eps)
+
 	add := func(addr string, ep v1.Endpoint, port v1.EndpointPort) {
+		if !appProtocolIncluded(e.appProtocols, port.AppProtocol) {
+			return
+		}
+		if duplicates != nil {
+			if _, ok := duplicates[endpointAddressKey(addr, port)]; ok {
+				return
+			}
+		}
 		a := addr
 		if port.Port != nil {
 			a = net.JoinHostPort(addr, strconv.FormatUint(uint64(*port.Port), 10))
@@ -372,6 +612,14 @@ func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Gr
 			target[model.LabelName(endpointSliceEndpointZoneLabel)] = lv(*ep.Zone)
 		}
 
+		if ep.Hints != nil && len(ep.Hints.ForZones) > 0 {
+			zones := make([]string, 0, len(ep.Hints.ForZones))
+			for _, z := range ep.Hints.ForZones {
+				zones = append(zones, z.Name)
+			}
+			target[endpointSliceEndpointHintsForZonesLabel] = lv(strings.Join(zones, ","))
+		}
+
 		for k, v := range ep.DeprecatedTopology {
 			ln := strutil.SanitizeLabelName(k)
 			target[model.LabelName(endpointSliceEndpointTopologyLabelPrefix+ln)] = lv(v)
@@ -380,8 +628,15 @@ func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Gr
 
 		if e.withNodeMetadata {
 			if ep.TargetRef != nil && ep.TargetRef.Kind == "Node" {
+				if nodeExcludedByTaint(e.nodeInf, ep.TargetRef.Name, e.excludeNodeTaints) {
+					// Scheduled onto an excluded node, drop the target.
+					return
+				}
 				target = addNodeLabels(target, e.nodeInf, e.logger, &ep.TargetRef.Name)
 			} else {
+				if ep.NodeName != nil && nodeExcludedByTaint(e.nodeInf, *ep.NodeName, e.excludeNodeTaints) {
+					return
+				}
 				target = addNodeLabels(target, e.nodeInf, e.logger, ep.NodeName)
 			}
 		}
@@ -402,6 +657,16 @@ func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Gr
 
 		// Attach standard pod labels.
 		target = target.Merge(podLabels(pod))
+		addStatefulSetLabels(target, pod)
+		if e.withOwnerWorkload {
+			addOwnerWorkloadLabels(target, pod, e.replicaSetInf, e.logger)
+		}
+
+		// Expose when the pod's readiness last changed, so relabeling can
+		// implement min-ready-seconds style logic downstream.
+		if t := podReadyTransitionTime(pod); !t.IsZero() {
+			target[endpointSliceEndpointReadySinceLabel] = lv(t.Format(time.RFC3339))
+		}
 
 		// Attach potential container port labels matching the endpoint port.
 		containers := append(pod.Spec.Containers, pod.Spec.InitContainers...)
@@ -479,7 +744,12 @@ func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Gr
 					podContainerPortProtocolLabel: lv(string(cport.Protocol)),
 					podContainerIsInit:            lv(strconv.FormatBool(isInit)),
 				}
-				tg.Targets = append(tg.Targets, target.Merge(podLabels(pe.pod)))
+				target = target.Merge(podLabels(pe.pod))
+				addStatefulSetLabels(target, pe.pod)
+				if e.withOwnerWorkload {
+					addOwnerWorkloadLabels(target, pe.pod, e.replicaSetInf, e.logger)
+				}
+				tg.Targets = append(tg.Targets, target)
 			}
 		}
 	}
@@ -487,6 +757,60 @@ func (e *EndpointSlice) buildEndpointSlice(eps v1.EndpointSlice) *targetgroup.Gr
 	return tg
 }
 
+// endpointAddressKey identifies an endpoint within a service: distinct ports
+// or protocols on the same address stay distinct and are never merged.
+func endpointAddressKey(addr string, port v1.EndpointPort) string {
+	key := addr
+	if port.Port != nil {
+		key = net.JoinHostPort(addr, strconv.FormatUint(uint64(*port.Port), 10))
+	}
+	if port.Protocol != nil {
+		key += "/" + string(*port.Protocol)
+	}
+	return key
+}
+
+// duplicateAddresses returns the endpoint addresses of the given slice that
+// are already covered by a sibling slice of the same service, or nil if
+// deduplication is disabled or the slice has no siblings. Ownership of an
+// overlapping address goes to the slice whose name sorts first, so all
+// slices converge on the same single target while the control plane moves
+// endpoints between slices.
+func (e *EndpointSlice) duplicateAddresses(eps v1.EndpointSlice) map[string]struct{} {
+	if !e.deduplicateEndpoints {
+		return nil
+	}
+	svcName, found := eps.Labels[v1.LabelServiceName]
+	if !found {
+		return nil
+	}
+
+	siblings, err := e.endpointSliceInf.GetIndexer().ByIndex(serviceIndex, namespacedName(eps.Namespace, svcName))
+	if err != nil {
+		e.logger.Error("getting endpoint slices by service name failed", "err", err)
+		return nil
+	}
+
+	var duplicates map[string]struct{}
+	for _, sibling := range siblings {
+		s, ok := sibling.(*v1.EndpointSlice)
+		if !ok || s.AddressType != eps.AddressType || s.Name >= eps.Name {
+			continue
+		}
+		for _, ep := range s.Endpoints {
+			for _, port := range s.Ports {
+				for _, addr := range ep.Addresses {
+					if duplicates == nil {
+						duplicates = map[string]struct{}{}
+					}
+					duplicates[endpointAddressKey(addr, port)] = struct{}{}
+				}
+			}
+		}
+	}
+	return duplicates
+}
+
 func (e *EndpointSlice) resolvePodRef(ref *apiv1.ObjectReference) *apiv1.Pod {
 	if ref == nil || ref.Kind != "Pod" {
 		return nil
@@ -516,6 +840,9 @@ func (e *EndpointSlice) addServiceLabels(esa v1.EndpointSlice, tg *targetgroup.G
 	if !found {
 		return
 	}
+	// A clean copy of the owning service's name, independent of the label
+	// name mangling applied to the raw kubernetes.io/service-name label.
+	tg.Labels[endpointSliceServiceNameLabel] = lv(name)
 
 	obj, exists, err := e.serviceStore.GetByKey(namespacedName(ns, name))
 	if err != nil {
@@ -17,14 +17,20 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/prometheus/prometheus/discovery/targetgroup"
 )
@@ -124,6 +130,78 @@ func makeNamespace(name string, labels, annotations map[string]string) *corev1.N
 	}
 }
 
+func TestEndpointSliceDiscoveryHintsForZones(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := &v1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testhints",
+					Namespace: "default",
+				},
+				AddressType: v1.AddressTypeIPv4,
+				Ports: []v1.EndpointPort{
+					{
+						Name:     strptr("testport"),
+						Port:     int32ptr(9000),
+						Protocol: protocolptr(corev1.ProtocolTCP),
+					},
+				},
+				Endpoints: []v1.Endpoint{
+					{
+						Addresses: []string{"1.2.3.4"},
+						Conditions: v1.EndpointConditions{
+							Ready: boolptr(true),
+						},
+						Zone: strptr("us-east-1a"),
+						Hints: &v1.EndpointHints{
+							ForZones: []v1.ForZone{{Name: "us-east-1a"}, {Name: "us-east-1b"}},
+						},
+					}, {
+						Addresses: []string{"2.3.4.5"},
+						Conditions: v1.EndpointConditions{
+							Ready: boolptr(true),
+						},
+					},
+				},
+			}
+			c.DiscoveryV1().EndpointSlices(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/testhints": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_endpoint_zone":             "us-east-1a",
+						"__meta_kubernetes_endpointslice_endpoint_hints_for_zones":  "us-east-1a,us-east-1b",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+					{
+						"__address__": "2.3.4.5:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_namespace":                  "default",
+					"__meta_kubernetes_endpointslice_name":         "testhints",
+					"__meta_kubernetes_endpointslice_address_type": "IPv4",
+				},
+				Source: "endpointslice/default/testhints",
+			},
+		},
+	}.Run(t)
+}
+
 func TestEndpointSliceDiscoveryBeforeRun(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}})
@@ -197,6 +275,7 @@ func TestEndpointSliceDiscoveryBeforeRun(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 				},
@@ -206,6 +285,152 @@ func TestEndpointSliceDiscoveryBeforeRun(t *testing.T) {
 	}.Run(t)
 }
 
+func TestEndpointSliceDiscoveryFQDNAddressType(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			// FQDN-typed slices carry hostnames instead of IPs; the hostname
+			// becomes the target address as-is.
+			obj := &v1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testfqdn",
+					Namespace: "default",
+				},
+				AddressType: v1.AddressTypeFQDN,
+				Ports: []v1.EndpointPort{
+					{
+						Name:     strptr("testport"),
+						Port:     int32ptr(5432),
+						Protocol: protocolptr(corev1.ProtocolTCP),
+					},
+				},
+				Endpoints: []v1.Endpoint{
+					{
+						Addresses: []string{"db.example.com"},
+						Conditions: v1.EndpointConditions{
+							Ready: boolptr(true),
+						},
+					},
+				},
+			}
+			c.DiscoveryV1().EndpointSlices(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/testfqdn": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "db.example.com:5432",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_port":                      "5432",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_endpointslice_address_type": "FQDN",
+					"__meta_kubernetes_namespace":                  "default",
+					"__meta_kubernetes_endpointslice_name":         "testfqdn",
+				},
+				Source: "endpointslice/default/testfqdn",
+			},
+		},
+	}.Run(t)
+}
+
+func TestEndpointSliceDiscoveryHeadlessServiceHostnames(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			// A StatefulSet behind a headless service gets one endpoint per
+			// pod, each carrying the pod's stable DNS hostname. The hostname
+			// label lets relabeling scrape the per-pod DNS names instead of
+			// the ephemeral IPs.
+			obj := &v1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testheadless",
+					Namespace: "default",
+				},
+				AddressType: v1.AddressTypeIPv4,
+				Ports: []v1.EndpointPort{
+					{
+						Name:     strptr("testport"),
+						Port:     int32ptr(9000),
+						Protocol: protocolptr(corev1.ProtocolTCP),
+					},
+				},
+				Endpoints: []v1.Endpoint{
+					{
+						Addresses: []string{"10.0.0.1"},
+						Hostname:  strptr("web-0"),
+						Conditions: v1.EndpointConditions{
+							Ready: boolptr(true),
+						},
+					},
+					{
+						Addresses: []string{"10.0.0.2"},
+						Hostname:  strptr("web-1"),
+						Conditions: v1.EndpointConditions{
+							Ready: boolptr(true),
+						},
+					},
+					{
+						// No hostname, e.g. a mirrored legacy endpoint; the
+						// label is omitted.
+						Addresses: []string{"10.0.0.3"},
+						Conditions: v1.EndpointConditions{
+							Ready: boolptr(true),
+						},
+					},
+				},
+			}
+			c.DiscoveryV1().EndpointSlices(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/testheadless": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "10.0.0.1:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_endpoint_hostname":         "web-0",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+					{
+						"__address__": "10.0.0.2:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_endpoint_hostname":         "web-1",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+					{
+						"__address__": "10.0.0.3:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_endpointslice_address_type": "IPv4",
+					"__meta_kubernetes_namespace":                  "default",
+					"__meta_kubernetes_endpointslice_name":         "testheadless",
+				},
+				Source: "endpointslice/default/testheadless",
+			},
+		},
+	}.Run(t)
+}
+
 func TestEndpointSliceDiscoveryAdd(t *testing.T) {
 	t.Parallel()
 	obj := &corev1.Pod{
@@ -334,6 +559,224 @@ func TestEndpointSliceDiscoveryAdd(t *testing.T) {
 	}.Run(t)
 }
 
+func TestEndpointSliceDiscoveryReadySince(t *testing.T) {
+	t.Parallel()
+	readySince := metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	obj := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testpod",
+			Namespace: "default",
+			UID:       types.UID("deadbeef"),
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "testnode",
+			Containers: []corev1.Container{
+				{
+					Name:  "c1",
+					Image: "c1:latest",
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "mainport",
+							ContainerPort: 9000,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			HostIP: "2.3.4.5",
+			PodIP:  "1.2.3.4",
+			Conditions: []corev1.PodCondition{
+				{
+					Type:               corev1.PodReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: readySince,
+				},
+			},
+		},
+	}
+	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}}, obj)
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := &v1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testendpoints",
+					Namespace: "default",
+				},
+				AddressType: v1.AddressTypeIPv4,
+				Ports: []v1.EndpointPort{
+					{
+						Name:     strptr("testport"),
+						Port:     int32ptr(9000),
+						Protocol: protocolptr(corev1.ProtocolTCP),
+					},
+				},
+				Endpoints: []v1.Endpoint{
+					{
+						Addresses: []string{"1.2.3.4"},
+						TargetRef: &corev1.ObjectReference{
+							Kind:      "Pod",
+							Name:      "testpod",
+							Namespace: "default",
+						},
+						Conditions: v1.EndpointConditions{
+							Ready: boolptr(true),
+						},
+					},
+				},
+			}
+			c.DiscoveryV1().EndpointSlices(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/testendpoints": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:9000",
+						"__meta_kubernetes_endpointslice_address_target_kind":       "Pod",
+						"__meta_kubernetes_endpointslice_address_target_name":       "testpod",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_endpoint_ready_since":      "2024-01-02T03:04:05Z",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+						"__meta_kubernetes_pod_container_name":                      "c1",
+						"__meta_kubernetes_pod_container_image":                     "c1:latest",
+						"__meta_kubernetes_pod_container_port_name":                 "mainport",
+						"__meta_kubernetes_pod_container_port_number":               "9000",
+						"__meta_kubernetes_pod_container_port_protocol":             "TCP",
+						"__meta_kubernetes_pod_host_ip":                             "2.3.4.5",
+						"__meta_kubernetes_pod_ip":                                  "1.2.3.4",
+						"__meta_kubernetes_pod_name":                                "testpod",
+						"__meta_kubernetes_pod_node_name":                           "testnode",
+						"__meta_kubernetes_pod_phase":                               "",
+						"__meta_kubernetes_pod_ready":                               "true",
+						"__meta_kubernetes_pod_uid":                                 "deadbeef",
+						"__meta_kubernetes_pod_container_init":                      "false",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_endpointslice_address_type": "IPv4",
+					"__meta_kubernetes_endpointslice_name":         "testendpoints",
+					"__meta_kubernetes_namespace":                  "default",
+				},
+				Source: "endpointslice/default/testendpoints",
+			},
+		},
+	}.Run(t)
+}
+
+func TestEndpointSliceDiscoveryStatefulSetOrdinal(t *testing.T) {
+	t.Parallel()
+	obj := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-0",
+			Namespace: "default",
+			UID:       types.UID("deadbeef"),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind:       "StatefulSet",
+					Name:       "web",
+					Controller: boolptr(true),
+				},
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "testnode",
+			Containers: []corev1.Container{
+				{
+					Name:  "c1",
+					Image: "c1:latest",
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "mainport",
+							ContainerPort: 9000,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			HostIP: "2.3.4.5",
+			PodIP:  "1.2.3.4",
+		},
+	}
+	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}}, obj)
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := &v1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testendpoints",
+					Namespace: "default",
+				},
+				AddressType: v1.AddressTypeIPv4,
+				Ports: []v1.EndpointPort{
+					{
+						Name:     strptr("testport"),
+						Port:     int32ptr(9000),
+						Protocol: protocolptr(corev1.ProtocolTCP),
+					},
+				},
+				Endpoints: []v1.Endpoint{
+					{
+						Addresses: []string{"1.2.3.4"},
+						TargetRef: &corev1.ObjectReference{
+							Kind:      "Pod",
+							Name:      "web-0",
+							Namespace: "default",
+						},
+					},
+				},
+			}
+			c.DiscoveryV1().EndpointSlices(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/testendpoints": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:9000",
+						"__meta_kubernetes_endpointslice_address_target_kind": "Pod",
+						"__meta_kubernetes_endpointslice_address_target_name": "web-0",
+						"__meta_kubernetes_endpointslice_port":                "9000",
+						"__meta_kubernetes_endpointslice_port_name":           "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":       "TCP",
+						"__meta_kubernetes_pod_container_name":                "c1",
+						"__meta_kubernetes_pod_container_image":               "c1:latest",
+						"__meta_kubernetes_pod_container_port_name":           "mainport",
+						"__meta_kubernetes_pod_container_port_number":         "9000",
+						"__meta_kubernetes_pod_container_port_protocol":       "TCP",
+						"__meta_kubernetes_pod_container_init":                "false",
+						"__meta_kubernetes_pod_controller_kind":               "StatefulSet",
+						"__meta_kubernetes_pod_controller_name":               "web",
+						"__meta_kubernetes_pod_host_ip":                       "2.3.4.5",
+						"__meta_kubernetes_pod_ip":                            "1.2.3.4",
+						"__meta_kubernetes_pod_name":                          "web-0",
+						"__meta_kubernetes_pod_node_name":                     "testnode",
+						"__meta_kubernetes_pod_phase":                         "",
+						"__meta_kubernetes_pod_ready":                         "unknown",
+						"__meta_kubernetes_pod_statefulset_name":              "web",
+						"__meta_kubernetes_pod_statefulset_ordinal":           "0",
+						"__meta_kubernetes_pod_uid":                           "deadbeef",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_endpointslice_address_type": "IPv4",
+					"__meta_kubernetes_endpointslice_name":         "testendpoints",
+					"__meta_kubernetes_namespace":                  "default",
+				},
+				Source: "endpointslice/default/testendpoints",
+			},
+		},
+	}.Run(t)
+}
+
 func TestEndpointSliceDiscoveryDelete(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}}, makeEndpointSliceV1("default"))
@@ -429,6 +872,7 @@ func TestEndpointSliceDiscoveryEmptyEndpoints(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             "default",
@@ -519,6 +963,7 @@ func TestEndpointSliceDiscoveryWithService(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             "default",
@@ -625,6 +1070,7 @@ func TestEndpointSliceDiscoveryWithServiceUpdate(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             "default",
@@ -682,6 +1128,7 @@ func TestEndpointsSlicesDiscoveryWithNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":                                    "us-east1",
 						"__meta_kubernetes_node_labelpresent_az":                             "true",
 						"__meta_kubernetes_node_name":                                        "foobar",
+						"__meta_kubernetes_node_unschedulable":                               "false",
 					},
 					{
 						"__address__": "2.3.4.5:9000",
@@ -720,6 +1167,7 @@ func TestEndpointsSlicesDiscoveryWithNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":                                 "us-west2",
 						"__meta_kubernetes_node_labelpresent_az":                          "true",
 						"__meta_kubernetes_node_name":                                     "barbaz",
+						"__meta_kubernetes_node_unschedulable":                            "false",
 					},
 				},
 				Labels: model.LabelSet{
@@ -727,6 +1175,7 @@ func TestEndpointsSlicesDiscoveryWithNodeMetadata(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             "default",
@@ -788,6 +1237,7 @@ func TestEndpointsSlicesDiscoveryWithUpdatedNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":                                    "us-east1",
 						"__meta_kubernetes_node_labelpresent_az":                             "true",
 						"__meta_kubernetes_node_name":                                        "foobar",
+						"__meta_kubernetes_node_unschedulable":                               "false",
 					},
 					{
 						"__address__": "2.3.4.5:9000",
@@ -826,6 +1276,7 @@ func TestEndpointsSlicesDiscoveryWithUpdatedNodeMetadata(t *testing.T) {
 						"__meta_kubernetes_node_label_az":                                 "us-west2",
 						"__meta_kubernetes_node_labelpresent_az":                          "true",
 						"__meta_kubernetes_node_name":                                     "barbaz",
+						"__meta_kubernetes_node_unschedulable":                            "false",
 					},
 				},
 				Labels: model.LabelSet{
@@ -833,6 +1284,7 @@ func TestEndpointsSlicesDiscoveryWithUpdatedNodeMetadata(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             "default",
@@ -979,6 +1431,7 @@ func TestEndpointSliceDiscoveryNamespaces(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             "ns1",
@@ -1135,6 +1588,7 @@ func TestEndpointSliceDiscoveryOwnNamespace(t *testing.T) {
 					"__meta_kubernetes_namespace":                                             "own-ns",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 				},
@@ -1474,6 +1928,7 @@ func TestEndpointsSlicesDiscoveryWithNamespaceMetadata(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             model.LabelValue(ns),
@@ -1587,6 +2042,7 @@ func TestEndpointsSlicesDiscoveryWithUpdatedNamespaceMetadata(t *testing.T) {
 					"__meta_kubernetes_endpointslice_name":                                    "testendpoints",
 					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testendpoints",
 					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testendpoints",
 					"__meta_kubernetes_endpointslice_annotation_test_annotation":              "test",
 					"__meta_kubernetes_endpointslice_annotationpresent_test_annotation":       "true",
 					"__meta_kubernetes_namespace":                                             model.LabelValue(ns),
@@ -1611,3 +2067,299 @@ func TestEndpointsSlicesDiscoveryWithUpdatedNamespaceMetadata(t *testing.T) {
 		},
 	}.Run(t)
 }
+
+func TestEndpointSliceDiscoveryGroupByService(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}})
+	n.groupByService = true
+
+	makeSlice := func(name string, addresses ...string) *v1.EndpointSlice {
+		eps := &v1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					v1.LabelServiceName: "testsvc",
+				},
+			},
+			AddressType: v1.AddressTypeIPv4,
+			Ports: []v1.EndpointPort{
+				{
+					Name:     strptr("testport"),
+					Port:     int32ptr(9000),
+					Protocol: protocolptr(corev1.ProtocolTCP),
+				},
+			},
+		}
+		for _, addr := range addresses {
+			eps.Endpoints = append(eps.Endpoints, v1.Endpoint{
+				Addresses: []string{addr},
+				Conditions: v1.EndpointConditions{
+					Ready: boolptr(true),
+				},
+			})
+		}
+		return eps
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			for i, name := range []string{"testslice-1", "testslice-2", "testslice-3"} {
+				obj := makeSlice(name, fmt.Sprintf("10.0.0.%d", i+1))
+				c.DiscoveryV1().EndpointSlices(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+			}
+		},
+		afterStart: func() {
+			// Deleting one slice recomputes the merged group from the rest.
+			c.DiscoveryV1().EndpointSlices("default").Delete(context.Background(), "testslice-2", metav1.DeleteOptions{})
+		},
+		expectedMaxItems: 4,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/service/testsvc": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "10.0.0.1:9000",
+						"__meta_kubernetes_endpointslice_address_type":                            "IPv4",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready":               "true",
+						"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testsvc",
+						"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+						"__meta_kubernetes_endpointslice_name":                                    "testslice-1",
+						"__meta_kubernetes_endpointslice_port":                                    "9000",
+						"__meta_kubernetes_endpointslice_port_name":                               "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":                           "TCP",
+						"__meta_kubernetes_endpointslice_service_name":                            "testsvc",
+						"__meta_kubernetes_namespace":                                             "default",
+					},
+					{
+						"__address__": "10.0.0.3:9000",
+						"__meta_kubernetes_endpointslice_address_type":                            "IPv4",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready":               "true",
+						"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testsvc",
+						"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+						"__meta_kubernetes_endpointslice_name":                                    "testslice-3",
+						"__meta_kubernetes_endpointslice_port":                                    "9000",
+						"__meta_kubernetes_endpointslice_port_name":                               "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":                           "TCP",
+						"__meta_kubernetes_endpointslice_service_name":                            "testsvc",
+						"__meta_kubernetes_namespace":                                             "default",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_endpointslice_service_name": "testsvc",
+					"__meta_kubernetes_namespace":                  "default",
+				},
+				Source: "endpointslice/default/service/testsvc",
+			},
+		},
+	}.Run(t)
+}
+
+func TestEndpointSliceDiscoveryDeduplicate(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleEndpointSlice, NamespaceDiscovery{Names: []string{"default"}})
+	n.deduplicateEndpoints = true
+
+	makeSlice := func(name string, addresses ...string) *v1.EndpointSlice {
+		eps := &v1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					v1.LabelServiceName: "testsvc",
+				},
+			},
+			AddressType: v1.AddressTypeIPv4,
+			Ports: []v1.EndpointPort{
+				{
+					Name:     strptr("testport"),
+					Port:     int32ptr(9000),
+					Protocol: protocolptr(corev1.ProtocolTCP),
+				},
+			},
+		}
+		for _, addr := range addresses {
+			eps.Endpoints = append(eps.Endpoints, v1.Endpoint{
+				Addresses: []string{addr},
+				Conditions: v1.EndpointConditions{
+					Ready: boolptr(true),
+				},
+			})
+		}
+		return eps
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			// While the control plane resizes the service's slices, the
+			// moving endpoint 10.0.0.2 is briefly present in both. Only the
+			// first slice by name keeps it; the second emits the remainder.
+			objA := makeSlice("testslice-a", "10.0.0.1", "10.0.0.2")
+			objB := makeSlice("testslice-b", "10.0.0.2", "10.0.0.3")
+			c.DiscoveryV1().EndpointSlices(objA.Namespace).Create(context.Background(), objA, metav1.CreateOptions{})
+			c.DiscoveryV1().EndpointSlices(objB.Namespace).Create(context.Background(), objB, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/testslice-a": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "10.0.0.1:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+					{
+						"__address__": "10.0.0.2:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_endpointslice_address_type":                            "IPv4",
+					"__meta_kubernetes_endpointslice_name":                                    "testslice-a",
+					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testsvc",
+					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testsvc",
+					"__meta_kubernetes_namespace":                                             "default",
+				},
+				Source: "endpointslice/default/testslice-a",
+			},
+			"endpointslice/default/testslice-b": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "10.0.0.3:9000",
+						"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+						"__meta_kubernetes_endpointslice_port":                      "9000",
+						"__meta_kubernetes_endpointslice_port_name":                 "testport",
+						"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_endpointslice_address_type":                            "IPv4",
+					"__meta_kubernetes_endpointslice_name":                                    "testslice-b",
+					"__meta_kubernetes_endpointslice_label_kubernetes_io_service_name":        "testsvc",
+					"__meta_kubernetes_endpointslice_labelpresent_kubernetes_io_service_name": "true",
+					"__meta_kubernetes_endpointslice_service_name":                            "testsvc",
+					"__meta_kubernetes_namespace":                                             "default",
+				},
+				Source: "endpointslice/default/testslice-b",
+			},
+		},
+	}.Run(t)
+}
+
+func makeServiceImportDiscovery() (*Discovery, kubernetes.Interface, dynamic.Interface) {
+	n, c := makeDiscovery(RoleServiceImport, NamespaceDiscovery{})
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{serviceImportGVR: "ServiceImportList"},
+	)
+	n.dynamicClient = dyn
+	return n, c, dyn
+}
+
+func makeServiceImport(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "multicluster.x-k8s.io/v1alpha1",
+		"kind":       "ServiceImport",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+	}}
+}
+
+func makeImportedEndpointSlice(name, service, cluster string) *v1.EndpointSlice {
+	return &v1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				mcsServiceNameLabel:   service,
+				mcsSourceClusterLabel: cluster,
+			},
+		},
+		AddressType: v1.AddressTypeIPv4,
+		Endpoints: []v1.Endpoint{{
+			Addresses:  []string{"10.0.0.1"},
+			Conditions: v1.EndpointConditions{Ready: boolptr(true)},
+		}},
+		Ports: []v1.EndpointPort{{
+			Name:     strptr("testport"),
+			Port:     int32ptr(9000),
+			Protocol: protocolptr(corev1.ProtocolTCP),
+		}},
+	}
+}
+
+func expectedServiceImportTargetGroups() map[string]*targetgroup.Group {
+	return map[string]*targetgroup.Group{
+		"endpointslice/default/testslice": {
+			Targets: []model.LabelSet{
+				{
+					"__address__": "10.0.0.1:9000",
+					"__meta_kubernetes_endpointslice_endpoint_conditions_ready": "true",
+					"__meta_kubernetes_endpointslice_port":                      "9000",
+					"__meta_kubernetes_endpointslice_port_name":                 "testport",
+					"__meta_kubernetes_endpointslice_port_protocol":             "TCP",
+				},
+			},
+			Labels: model.LabelSet{
+				"__meta_kubernetes_namespace":                                                            "default",
+				"__meta_kubernetes_endpointslice_address_type":                                           "IPv4",
+				"__meta_kubernetes_endpointslice_name":                                                   "testslice",
+				"__meta_kubernetes_endpointslice_label_multicluster_kubernetes_io_service_name":          "testsvc",
+				"__meta_kubernetes_endpointslice_labelpresent_multicluster_kubernetes_io_service_name":   "true",
+				"__meta_kubernetes_endpointslice_label_multicluster_kubernetes_io_source_cluster":        "cluster-a",
+				"__meta_kubernetes_endpointslice_labelpresent_multicluster_kubernetes_io_source_cluster": "true",
+				"__meta_kubernetes_mcs_cluster":                                                          "cluster-a",
+			},
+			Source: "endpointslice/default/testslice",
+		},
+	}
+}
+
+func TestServiceImportDiscovery(t *testing.T) {
+	t.Parallel()
+	n, c, dyn := makeServiceImportDiscovery()
+
+	expected := expectedServiceImportTargetGroups()
+	// The orphan slice names a ServiceImport that does not exist, so its
+	// targets are dropped.
+	expected["endpointslice/default/orphan"] = &targetgroup.Group{
+		Source: "endpointslice/default/orphan",
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			dyn.Resource(serviceImportGVR).Namespace("default").Create(context.Background(), makeServiceImport("testsvc"), metav1.CreateOptions{})
+			c.DiscoveryV1().EndpointSlices("default").Create(context.Background(), makeImportedEndpointSlice("testslice", "testsvc", "cluster-a"), metav1.CreateOptions{})
+			c.DiscoveryV1().EndpointSlices("default").Create(context.Background(), makeImportedEndpointSlice("orphan", "nosvc", "cluster-b"), metav1.CreateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestServiceImportDiscoveryDelete(t *testing.T) {
+	t.Parallel()
+	n, c, dyn := makeServiceImportDiscovery()
+	res := dyn.Resource(serviceImportGVR).Namespace("default")
+	res.Create(context.Background(), makeServiceImport("testsvc"), metav1.CreateOptions{})
+	c.DiscoveryV1().EndpointSlices("default").Create(context.Background(), makeImportedEndpointSlice("testslice", "testsvc", "cluster-a"), metav1.CreateOptions{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			res.Delete(context.Background(), "testsvc", metav1.DeleteOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"endpointslice/default/testslice": {
+				Source: "endpointslice/default/testslice",
+			},
+		},
+	}.Run(t)
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -32,27 +33,33 @@ import (
 
 // Ingress implements discovery of Kubernetes ingress.
 type Ingress struct {
-	logger                *slog.Logger
-	informer              cache.SharedIndexInformer
-	store                 cache.Store
-	queue                 *workqueue.Type
-	namespaceInf          cache.SharedInformer
-	withNamespaceMetadata bool
+	logger                   *slog.Logger
+	informer                 cache.SharedIndexInformer
+	store                    cache.Store
+	queue                    *workqueue.Type
+	namespaceInf             cache.SharedInformer
+	withNamespaceMetadata    bool
+	namespaceLabelPrefixes   []string
+	ingressClassInf          cache.SharedInformer
+	withIngressClassMetadata bool
 }
 
 // NewIngress returns a new ingress discovery.
-func NewIngress(l *slog.Logger, inf cache.SharedIndexInformer, namespace cache.SharedInformer, eventCount *prometheus.CounterVec) *Ingress {
+func NewIngress(l *slog.Logger, inf cache.SharedIndexInformer, namespace, ingressClass cache.SharedInformer, eventCount *prometheus.CounterVec, namespaceLabelPrefixes []string) *Ingress {
 	ingressAddCount := eventCount.WithLabelValues(RoleIngress.String(), MetricLabelRoleAdd)
 	ingressUpdateCount := eventCount.WithLabelValues(RoleIngress.String(), MetricLabelRoleUpdate)
 	ingressDeleteCount := eventCount.WithLabelValues(RoleIngress.String(), MetricLabelRoleDelete)
 
 	s := &Ingress{
-		logger:                l,
-		informer:              inf,
-		store:                 inf.GetStore(),
-		queue:                 workqueue.NewNamed(RoleIngress.String()),
-		namespaceInf:          namespace,
-		withNamespaceMetadata: namespace != nil,
+		logger:                   l,
+		informer:                 inf,
+		store:                    inf.GetStore(),
+		queue:                    workqueue.NewNamed(RoleIngress.String()),
+		namespaceInf:             namespace,
+		withNamespaceMetadata:    namespace != nil,
+		namespaceLabelPrefixes:   namespaceLabelPrefixes,
+		ingressClassInf:          ingressClass,
+		withIngressClassMetadata: ingressClass != nil,
 	}
 
 	_, err := s.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -87,9 +94,43 @@ func NewIngress(l *slog.Logger, inf cache.SharedIndexInformer, namespace cache.S
 		}
 	}
 
+	if s.withIngressClassMetadata {
+		_, err = s.ingressClassInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(o interface{}) {
+				s.enqueueIngressesForClass(o)
+			},
+			UpdateFunc: func(_, o interface{}) {
+				s.enqueueIngressesForClass(o)
+			},
+			DeleteFunc: func(o interface{}) {
+				s.enqueueIngressesForClass(o)
+			},
+		})
+		if err != nil {
+			l.Error("Error adding ingress classes event handler.", "err", err)
+		}
+	}
+
 	return s
 }
 
+// enqueueIngressesForClass re-enqueues all ingresses using the changed
+// ingress class, so their controller label follows the class.
+func (i *Ingress) enqueueIngressesForClass(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	class, ok := obj.(*v1.IngressClass)
+	if !ok {
+		return
+	}
+	for _, o := range i.store.List() {
+		if ingress, ok := o.(*v1.Ingress); ok && ingressClassName(*ingress) == class.Name {
+			i.enqueue(ingress)
+		}
+	}
+}
+
 func (i *Ingress) enqueue(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
@@ -119,6 +160,9 @@ func (i *Ingress) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
 	if i.withNamespaceMetadata {
 		cacheSyncs = append(cacheSyncs, i.namespaceInf.HasSynced)
 	}
+	if i.withIngressClassMetadata {
+		cacheSyncs = append(cacheSyncs, i.ingressClassInf.HasSynced)
+	}
 
 	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
 		if !errors.Is(ctx.Err(), context.Canceled) {
@@ -177,18 +221,34 @@ func ingressSourceFromNamespaceAndName(namespace, name string) string {
 }
 
 const (
-	ingressSchemeLabel    = metaLabelPrefix + "ingress_scheme"
-	ingressHostLabel      = metaLabelPrefix + "ingress_host"
-	ingressPathLabel      = metaLabelPrefix + "ingress_path"
-	ingressClassNameLabel = metaLabelPrefix + "ingress_class_name"
+	ingressSchemeLabel                 = metaLabelPrefix + "ingress_scheme"
+	ingressHostLabel                   = metaLabelPrefix + "ingress_host"
+	ingressPathLabel                   = metaLabelPrefix + "ingress_path"
+	ingressClassNameLabel              = metaLabelPrefix + "ingress_class_name"
+	ingressClassControllerLabel        = metaLabelPrefix + "ingress_class_controller"
+	ingressPathBackendServiceNameLabel = metaLabelPrefix + "ingress_path_backend_service_name"
+	ingressPathBackendServicePortLabel = metaLabelPrefix + "ingress_path_backend_service_port"
 )
 
+// ingressClassLegacyAnnotation is the deprecated way of naming an ingress
+// class, predating spec.ingressClassName and still set by older deployments.
+const ingressClassLegacyAnnotation = "kubernetes.io/ingress.class"
+
+// ingressClassName returns the name of the class the ingress uses, falling
+// back to the deprecated annotation when spec.ingressClassName is unset.
+func ingressClassName(ingress v1.Ingress) string {
+	if cls := ingress.Spec.IngressClassName; cls != nil {
+		return *cls
+	}
+	return ingress.Annotations[ingressClassLegacyAnnotation]
+}
+
 func ingressLabels(ingress v1.Ingress) model.LabelSet {
 	// Each label and annotation will create two key-value pairs in the map.
 	ls := make(model.LabelSet)
 	ls[namespaceLabel] = lv(ingress.Namespace)
-	if cls := ingress.Spec.IngressClassName; cls != nil {
-		ls[ingressClassNameLabel] = lv(*cls)
+	if cls := ingressClassName(ingress); cls != "" {
+		ls[ingressClassNameLabel] = lv(cls)
 	}
 
 	addObjectMetaLabels(ls, ingress.ObjectMeta, RoleIngress)
@@ -196,31 +256,25 @@ func ingressLabels(ingress v1.Ingress) model.LabelSet {
 	return ls
 }
 
-func pathsFromIngressPaths(ingressPaths []string) []string {
-	if ingressPaths == nil {
-		return []string{"/"}
-	}
-	paths := make([]string, len(ingressPaths))
-	for n, p := range ingressPaths {
-		path := p
-		if p == "" {
-			path = "/"
-		}
-		paths[n] = path
+func ingressTarget(host, path, scheme string, backend *v1.IngressBackend) model.LabelSet {
+	if path == "" {
+		path = "/"
 	}
-	return paths
-}
-
-func rulePaths(rule v1.IngressRule) []string {
-	rv := rule.IngressRuleValue
-	if rv.HTTP == nil {
-		return nil
+	t := model.LabelSet{
+		model.AddressLabel: lv(host),
+		ingressSchemeLabel: lv(scheme),
+		ingressHostLabel:   lv(host),
+		ingressPathLabel:   lv(path),
 	}
-	paths := make([]string, len(rv.HTTP.Paths))
-	for n, p := range rv.HTTP.Paths {
-		paths[n] = p.Path
+	if backend != nil && backend.Service != nil {
+		t[ingressPathBackendServiceNameLabel] = lv(backend.Service.Name)
+		if port := backend.Service.Port; port.Name != "" {
+			t[ingressPathBackendServicePortLabel] = lv(port.Name)
+		} else {
+			t[ingressPathBackendServicePortLabel] = lv(strconv.FormatInt(int64(port.Number), 10))
+		}
 	}
-	return paths
+	return t
 }
 
 func tlsHosts(ingressTLS []v1.IngressTLS) []string {
@@ -237,13 +291,23 @@ func (i *Ingress) buildIngress(ingress v1.Ingress) *targetgroup.Group {
 	}
 	tg.Labels = ingressLabels(ingress)
 
+	if i.withIngressClassMetadata {
+		if cls := ingressClassName(ingress); cls != "" {
+			obj, exists, err := i.ingressClassInf.GetStore().GetByKey(cls)
+			if err != nil {
+				i.logger.Error("getting ingress class failed", "class", cls, "err", err)
+			} else if class, ok := obj.(*v1.IngressClass); exists && ok {
+				tg.Labels[ingressClassControllerLabel] = lv(class.Spec.Controller)
+			}
+		}
+	}
+
 	if i.withNamespaceMetadata {
-		tg.Labels = addNamespaceLabels(tg.Labels, i.namespaceInf, i.logger, ingress.Namespace)
+		tg.Labels = addNamespaceLabels(tg.Labels, i.namespaceInf, i.logger, ingress.Namespace, i.namespaceLabelPrefixes)
 	}
 
 	for _, rule := range ingress.Spec.Rules {
 		scheme := "http"
-		paths := pathsFromIngressPaths(rulePaths(rule))
 
 	out:
 		for _, pattern := range tlsHosts(ingress.Spec.TLS) {
@@ -253,13 +317,14 @@ func (i *Ingress) buildIngress(ingress v1.Ingress) *targetgroup.Group {
 			}
 		}
 
-		for _, path := range paths {
-			tg.Targets = append(tg.Targets, model.LabelSet{
-				model.AddressLabel: lv(rule.Host),
-				ingressSchemeLabel: lv(scheme),
-				ingressHostLabel:   lv(rule.Host),
-				ingressPathLabel:   lv(path),
-			})
+		if rule.IngressRuleValue.HTTP == nil {
+			// Rules without an HTTP block route all traffic for the host
+			// through the default backend.
+			tg.Targets = append(tg.Targets, ingressTarget(rule.Host, "/", scheme, ingress.Spec.DefaultBackend))
+			continue
+		}
+		for _, path := range rule.IngressRuleValue.HTTP.Paths {
+			tg.Targets = append(tg.Targets, ingressTarget(rule.Host, path.Path, scheme, &path.Backend))
 		}
 	}
 
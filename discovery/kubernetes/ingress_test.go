@@ -92,6 +92,106 @@ func classString(v string) *string {
 	return &v
 }
 
+func makeIngressWithBackends(namespace string) *v1.Ingress {
+	return &v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testingress",
+			Namespace: namespace,
+		},
+		Spec: v1.IngressSpec{
+			DefaultBackend: &v1.IngressBackend{
+				Service: &v1.IngressServiceBackend{
+					Name: "fallback",
+					Port: v1.ServiceBackendPort{Number: 8080},
+				},
+			},
+			Rules: []v1.IngressRule{
+				{
+					Host: "example.com",
+					IngressRuleValue: v1.IngressRuleValue{
+						HTTP: &v1.HTTPIngressRuleValue{
+							Paths: []v1.HTTPIngressPath{
+								{
+									Path: "/",
+									Backend: v1.IngressBackend{
+										Service: &v1.IngressServiceBackend{
+											Name: "web",
+											Port: v1.ServiceBackendPort{Number: 80},
+										},
+									},
+								},
+								{
+									Path: "/api",
+									Backend: v1.IngressBackend{
+										Service: &v1.IngressServiceBackend{
+											Name: "api",
+											Port: v1.ServiceBackendPort{Name: "http-api"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					// No HTTP block: served by the default backend.
+					Host: "fallback.example.com",
+				},
+			},
+		},
+	}
+}
+
+func TestIngressDiscoveryBackends(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleIngress, NamespaceDiscovery{Names: []string{"default"}})
+
+	key := "ingress/default/testingress"
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeIngressWithBackends("default")
+			c.NetworkingV1().Ingresses("default").Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			key: {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                                         "example.com",
+						"__meta_kubernetes_ingress_scheme":                    "http",
+						"__meta_kubernetes_ingress_host":                      "example.com",
+						"__meta_kubernetes_ingress_path":                      "/",
+						"__meta_kubernetes_ingress_path_backend_service_name": "web",
+						"__meta_kubernetes_ingress_path_backend_service_port": "80",
+					},
+					{
+						"__address__":                                         "example.com",
+						"__meta_kubernetes_ingress_scheme":                    "http",
+						"__meta_kubernetes_ingress_host":                      "example.com",
+						"__meta_kubernetes_ingress_path":                      "/api",
+						"__meta_kubernetes_ingress_path_backend_service_name": "api",
+						"__meta_kubernetes_ingress_path_backend_service_port": "http-api",
+					},
+					{
+						"__address__":                                         "fallback.example.com",
+						"__meta_kubernetes_ingress_scheme":                    "http",
+						"__meta_kubernetes_ingress_host":                      "fallback.example.com",
+						"__meta_kubernetes_ingress_path":                      "/",
+						"__meta_kubernetes_ingress_path_backend_service_name": "fallback",
+						"__meta_kubernetes_ingress_path_backend_service_port": "8080",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_ingress_name": "testingress",
+					"__meta_kubernetes_namespace":    "default",
+				},
+				Source: key,
+			},
+		},
+	}.Run(t)
+}
+
 func expectedTargetGroups(ns string, tls TLSMode) map[string]*targetgroup.Group {
 	scheme1 := "http"
 	scheme2 := "http"
@@ -188,6 +288,56 @@ func TestIngressDiscoveryAddMixed(t *testing.T) {
 	}.Run(t)
 }
 
+func TestIngressDiscoveryClassAnnotationFallback(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleIngress, NamespaceDiscovery{Names: []string{"default"}})
+
+	obj := makeIngress("default", TLSNo)
+	obj.Spec.IngressClassName = nil
+	obj.Annotations["kubernetes.io/ingress.class"] = "testclass"
+
+	expected := expectedTargetGroups("default", TLSNo)
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_ingress_annotation_kubernetes_io_ingress_class"] = "testclass"
+		tg.Labels["__meta_kubernetes_ingress_annotationpresent_kubernetes_io_ingress_class"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			c.NetworkingV1().Ingresses("default").Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestIngressDiscoveryClassController(t *testing.T) {
+	t.Parallel()
+	class := &v1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "testclass",
+		},
+		Spec: v1.IngressClassSpec{
+			Controller: "example.com/ingress-controller",
+		},
+	}
+	n, c := makeDiscoveryWithMetadata(RoleIngress, NamespaceDiscovery{Names: []string{"default"}}, AttachMetadataConfig{IngressClass: true}, class)
+
+	expected := expectedTargetGroups("default", TLSNo)
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_ingress_class_controller"] = "example.com/ingress-controller"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeIngress("default", TLSNo)
+			c.NetworkingV1().Ingresses("default").Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
 func TestIngressDiscoveryNamespaces(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RoleIngress, NamespaceDiscovery{Names: []string{"ns1", "ns2"}})
@@ -17,27 +17,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
 	"os"
 	"reflect"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/promslog"
 	"github.com/prometheus/common/version"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	apiv1 "k8s.io/api/core/v1"
 	disv1 "k8s.io/api/discovery/v1"
 	networkv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // Required to get the GCP auth provider working.
 	"k8s.io/client-go/rest"
@@ -52,14 +64,20 @@ import (
 const (
 	// metaLabelPrefix is the meta prefix used for all meta labels.
 	// in this discovery.
-	metaLabelPrefix = model.MetaLabelPrefix + "kubernetes_"
-	namespaceLabel  = metaLabelPrefix + "namespace"
-	presentValue    = model.LabelValue("true")
+	metaLabelPrefix  = model.MetaLabelPrefix + "kubernetes_"
+	namespaceLabel   = metaLabelPrefix + "namespace"
+	clusterNameLabel = metaLabelPrefix + "cluster_name"
+	presentValue     = model.LabelValue("true")
+
+	// cacheObjectsUpdateInterval is how often the cache objects gauge is
+	// refreshed from the informer stores.
+	cacheObjectsUpdateInterval = 15 * time.Second
 )
 
 // DefaultSDConfig is the default Kubernetes SD configuration.
 var DefaultSDConfig = SDConfig{
-	HTTPClientConfig: config.DefaultHTTPClientConfig,
+	HTTPClientConfig:    config.DefaultHTTPClientConfig,
+	AllowWatchBookmarks: true,
 }
 
 func init() {
@@ -67,6 +85,29 @@ func init() {
 }
 
 // Role is role of the service in Kubernetes.
+//
+// Every role derives the Source of its target groups from the discovered
+// object's namespace and name only, so sources are deterministic and stable
+// across restarts and downstream staleness handling keeps working. The
+// per-role formats are:
+//
+//	endpoints, apiserver: endpoints/<namespace>/<name>
+//	endpointslice:        endpointslice/<namespace>/<name>
+//	                      (endpointslice/<namespace>/service/<service> with
+//	                      group_by_service, keyed by the owning service)
+//	service:              svc/<namespace>/<name>
+//	pod:                  pod/<namespace>/<name>
+//	ingress:              ingress/<namespace>/<name>
+//	cronjob:              cronjob/<namespace>/<name>
+//	pvc:                  pvc/<namespace>/<name>
+//	customresource:       customresource/<namespace>/<name>
+//	serviceimport:        endpointslice/<namespace>/<name>
+//	                      (one group per derived EndpointSlice)
+//	configmap:            configmap/<namespace>/<name>/<index>
+//	node:                 node/<name>
+//
+// These strings identify target groups across reloads and must not change
+// between releases.
 type Role string
 
 // The valid options for Role.
@@ -77,6 +118,27 @@ const (
 	RoleEndpoint      Role = "endpoints"
 	RoleEndpointSlice Role = "endpointslice"
 	RoleIngress       Role = "ingress"
+	RoleCronJob       Role = "cronjob"
+	// RolePVC discovers PersistentVolumeClaims, primarily so that CSI driver
+	// metrics endpoints can be assembled from claim metadata via relabeling.
+	RolePVC Role = "pvc"
+	// RoleCustomResource discovers instances of an arbitrary custom resource
+	// type, configured via the custom_resource block.
+	RoleCustomResource Role = "customresource"
+	// RoleServiceImport discovers the EndpointSlices derived from
+	// multicluster ServiceImports (the MCS API), i.e. endpoints imported
+	// from the other clusters of a ClusterSet, with the source cluster
+	// exposed as a meta label. It requires the multicluster.x-k8s.io CRDs
+	// to be installed.
+	RoleServiceImport Role = "serviceimport"
+	// RoleConfigMap discovers the static targets listed in a single ConfigMap
+	// key, in the same format file-based discovery reads. It is configured via
+	// the configmap_targets block.
+	RoleConfigMap Role = "configmap"
+	// RoleAPIServer discovers the endpoints of the API server itself, i.e.
+	// the "kubernetes" service in the "default" namespace, saving users the
+	// equivalent endpoints role plus relabel configuration.
+	RoleAPIServer Role = "apiserver"
 )
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -85,7 +147,7 @@ func (c *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 	switch *c {
-	case RoleNode, RolePod, RoleService, RoleEndpoint, RoleEndpointSlice, RoleIngress:
+	case RoleNode, RolePod, RoleService, RoleEndpoint, RoleEndpointSlice, RoleIngress, RoleCronJob, RolePVC, RoleCustomResource, RoleServiceImport, RoleConfigMap, RoleAPIServer:
 		return nil
 	default:
 		return fmt.Errorf("unknown Kubernetes SD role %q", *c)
@@ -102,20 +164,248 @@ const (
 	MetricLabelRoleUpdate = "update"
 )
 
+// Reasons for failed WATCH/LIST requests as exposed by the watch errors
+// metric. Permission errors point at an RBAC misconfiguration, while other
+// errors are typically transient.
+const (
+	MetricReasonForbidden    = "forbidden"
+	MetricReasonUnauthorized = "unauthorized"
+	MetricReasonOther        = "other"
+)
+
 // SDConfig is the configuration for Kubernetes service discovery.
 type SDConfig struct {
-	APIServer          config.URL              `yaml:"api_server,omitempty"`
-	Role               Role                    `yaml:"role"`
-	KubeConfig         string                  `yaml:"kubeconfig_file"`
+	APIServer   config.URL `yaml:"api_server,omitempty"`
+	Role        Role       `yaml:"role"`
+	KubeConfig  string     `yaml:"kubeconfig_file"`
+	KubeContext string     `yaml:"kube_context,omitempty"`
+	// ImpersonateUser makes every API request act as the given user via the
+	// Impersonate-User header, so a broadly-permissioned base identity can be
+	// narrowed to a restricted one. Requires 'api_server' or
+	// 'kubeconfig_file', as a base identity to impersonate from must exist.
+	ImpersonateUser string `yaml:"impersonate_user,omitempty"`
+	// ImpersonateGroups sets the Impersonate-Group headers sent alongside
+	// ImpersonateUser.
+	ImpersonateGroups  []string                `yaml:"impersonate_groups,omitempty"`
 	HTTPClientConfig   config.HTTPClientConfig `yaml:",inline"`
 	NamespaceDiscovery NamespaceDiscovery      `yaml:"namespaces,omitempty"`
 	Selectors          []SelectorConfig        `yaml:"selectors,omitempty"`
 	AttachMetadata     AttachMetadataConfig    `yaml:"attach_metadata,omitempty"`
+	ResyncPeriod       model.Duration          `yaml:"resync_period,omitempty"`
+	// IncludeVolumeMounts is opt-in due to the extra label cardinality: every
+	// volume mount of a container adds a label to its targets.
+	IncludeVolumeMounts bool `yaml:"include_volume_mounts,omitempty"`
+	// MaxNamespaces limits how many namespaces may be discovered, as every
+	// namespace spawns its own set of informers. Zero means unlimited.
+	MaxNamespaces int `yaml:"max_namespaces,omitempty"`
+	// IncludeSpecHash emits a hash of the scrape-relevant fields of each
+	// object, so external systems can detect no-op updates.
+	IncludeSpecHash bool `yaml:"include_spec_hash,omitempty"`
+	// SplitNodeEndpoints makes the node role emit separate kubelet and cAdvisor
+	// targets per node, so a single scrape config can relabel the metrics path
+	// based on the endpoint kind instead of being duplicated.
+	SplitNodeEndpoints bool `yaml:"split_node_endpoints,omitempty"`
+	// NodeAddressPriority overrides the order in which node address types are
+	// considered for the node role's target address. Each entry is an address
+	// type such as InternalIP or InternalDNS, optionally suffixed with /IPv4
+	// or /IPv6 to restrict it to one IP family, e.g. "InternalIP/IPv6" in
+	// dual-stack clusters. Empty keeps the default priority.
+	NodeAddressPriority []string `yaml:"node_address_priority,omitempty"`
+	// DefaultMetricsPort makes the pod role emit a single target per pod on
+	// the given port instead of one target per declared container port.
+	DefaultMetricsPort int `yaml:"default_metrics_port,omitempty"`
+	// OnlyNamedPorts makes the pod role skip container ports without a name,
+	// which are typically not meant to be scraped.
+	OnlyNamedPorts bool `yaml:"only_named_ports,omitempty"`
+	// PortAnnotation names a pod annotation holding a JSON array of
+	// {"port", "path", "scheme"} objects; annotated pods then emit one
+	// target per entry instead of one per declared container port, so a
+	// single pod can declare several scrape endpoints. Pods without the
+	// annotation are unaffected. Empty disables the convention.
+	PortAnnotation string `yaml:"port_annotation,omitempty"`
+	// RequirePodIP makes the pod role hold back pods that have not been
+	// assigned a pod IP yet, instead of emitting port-less targets that are
+	// immediately dropped. Targets appear with the update event that carries
+	// the IP.
+	RequirePodIP bool `yaml:"require_pod_ip,omitempty"`
+	// LeaderElection makes the pod role emit a __meta_kubernetes_pod_is_leader
+	// label for pods holding a leadership, so active/standby controllers can
+	// be told apart and only the active replica scraped for certain metrics.
+	LeaderElection *LeaderElectionConfig `yaml:"leader_election,omitempty"`
+	// ExcludeTerminating makes the pod role drop pods whose deletion
+	// timestamp is set, so targets disappear as soon as a pod starts
+	// terminating instead of producing scrape noise until it is gone.
+	ExcludeTerminating bool `yaml:"exclude_terminating,omitempty"`
+	// PodTargetsPerPod makes the pod role emit exactly one target per pod,
+	// addressed by the pod IP without a port, leaving the port to relabeling.
+	// The container meta labels describe the pod's first container only.
+	PodTargetsPerPod bool `yaml:"pod_targets_per_pod,omitempty"`
+	// EndpointPortNamePattern restricts the endpoints role to subset ports
+	// whose name matches the anchored regular expression. Port names are not
+	// selectable server-side, so the filter runs while building targets. The
+	// extra targets for container ports not covered by the service are
+	// filtered by their container port name likewise. Empty disables the
+	// filter.
+	EndpointPortNamePattern string `yaml:"endpoint_port_name_pattern,omitempty"`
+	// AppProtocols restricts the service and endpointslice roles to ports whose
+	// appProtocol is one of the listed values, e.g. to scrape only http, https
+	// and grpc ports and skip TCP-only ones such as databases. Ports without a
+	// declared appProtocol are always included. Empty disables the filter.
+	AppProtocols []string `yaml:"app_protocols,omitempty"`
+	// AllowWatchBookmarks lets the API server send watch bookmark events, so
+	// watches resume from a recent resourceVersion after a disconnect instead
+	// of re-listing the full resource set. All supported servers handle
+	// bookmarks; the toggle exists as an escape hatch only.
+	AllowWatchBookmarks bool `yaml:"allow_watch_bookmarks,omitempty"`
+	// GroupByService makes the endpointslice role key target groups by the
+	// owning service instead of by slice, merging all slices of a service
+	// into one group. This keeps a logical service in a single group even
+	// when the control plane spreads its endpoints over several slices.
+	GroupByService bool `yaml:"group_by_service,omitempty"`
+	// DeduplicateEndpoints makes the endpointslice role drop targets that
+	// already appear in another slice of the same service with the same
+	// address and port, e.g. while the control plane is resizing slices.
+	// Without it, such transient overlaps briefly produce duplicate targets.
+	DeduplicateEndpoints bool `yaml:"deduplicate_endpoints,omitempty"`
+	// LoadBalancerIngressTargets makes the service role emit an extra target
+	// for each address in a LoadBalancer service's ingress status, e.g. to
+	// probe the external IP. Services without a load balancer status are
+	// unaffected.
+	LoadBalancerIngressTargets bool `yaml:"loadbalancer_ingress_targets,omitempty"`
+	// SelectorFromConfigMap reads the label selector for the configured role
+	// from a ConfigMap key and re-applies it when the ConfigMap changes,
+	// without requiring a configuration reload.
+	SelectorFromConfigMap *SelectorFromConfigMapConfig `yaml:"selector_from_configmap,omitempty"`
+	// CustomResource identifies the custom resource type watched by the
+	// customresource role, along with a JSONPath expression extracting the
+	// target address from each object.
+	CustomResource *CustomResourceConfig `yaml:"custom_resource,omitempty"`
+	// SelectorFromCustomResource gates discovery on label selectors carried by
+	// instances of a PodMonitor-like custom resource: only objects matched by
+	// at least one of those selectors are discovered.
+	SelectorFromCustomResource *SelectorFromCustomResourceConfig `yaml:"selector_from_custom_resource,omitempty"`
+	// ConfigMapTargets locates the ConfigMap and data key holding the static
+	// target list read by the configmap role.
+	ConfigMapTargets *ConfigMapTargetsConfig `yaml:"configmap_targets,omitempty"`
+	// TrimObjectCache strips fields that discovery never reads (managed
+	// fields, the last-applied-configuration annotation and container
+	// environment variables) before objects enter the informer caches. Opt-in
+	// because relabeling on the stripped fields stops working.
+	TrimObjectCache bool `yaml:"trim_object_cache,omitempty"`
+	// MaxFailures stops discovery after that many failed WATCH/LIST requests
+	// instead of retrying indefinitely, e.g. when the resource type is
+	// permanently unavailable. Zero means unlimited retries.
+	MaxFailures int `yaml:"max_failures,omitempty"`
+	// ClusterName, when set, attaches a __meta_kubernetes_cluster_name label
+	// to every target regardless of role, so identical scrape configurations
+	// can be run across clusters in federated setups without a per-job static
+	// relabel. Empty attaches nothing.
+	ClusterName string `yaml:"cluster_name,omitempty"`
+	// SanitizeLabelValues replaces invalid UTF-8 sequences in meta label
+	// values with the Unicode replacement character instead of emitting them
+	// verbatim, e.g. when an annotation carries arbitrary binary data.
+	// Without it, a single invalid value makes downstream consumers reject
+	// the whole target group.
+	SanitizeLabelValues bool `yaml:"sanitize_label_values,omitempty"`
+	// UserAgentSuffix is appended to the User-Agent header of API server
+	// requests, e.g. to tell Prometheus instances apart in audit logs.
+	UserAgentSuffix string `yaml:"user_agent_suffix,omitempty"`
+	// ContentType selects the wire format requested from the API server,
+	// either "protobuf" (the default) or "json" for API servers and proxies
+	// that do not speak protobuf.
+	ContentType string `yaml:"content_type,omitempty"`
+	// ListPageSize caps the number of objects returned per LIST request,
+	// letting the reflector paginate the initial listing on large clusters.
+	// Zero keeps client-go's default behavior.
+	ListPageSize int64 `yaml:"list_page_size,omitempty"`
+	// ListFromWatchCache makes the initial LIST requests pass
+	// resourceVersion="0", letting the API server answer from its watch cache
+	// instead of performing a quorum read against etcd. This trades strong
+	// consistency for lower etcd load: the initial data may be slightly
+	// stale, which the subsequent watch catches up on. The API server ignores
+	// pagination for such lists, so 'list_page_size' has no effect on them.
+	ListFromWatchCache bool `yaml:"list_from_watch_cache,omitempty"`
+	// MaxConcurrentInformerStart limits how many informers perform their
+	// initial LIST at the same time: each informer starts only once a slot is
+	// free, and releases it when its cache has synced. This staggers the
+	// startup load on the API server when many namespaces are watched. Zero
+	// means unlimited.
+	MaxConcurrentInformerStart int `yaml:"max_concurrent_informer_start,omitempty"`
+	// SyncTimeout bounds how long discovery waits for the initial cache sync.
+	// When exceeded, an error is logged and an empty target group is emitted
+	// so downstream knows discovery produced no results, e.g. when RBAC
+	// blocks listing a resource. Zero means no timeout.
+	SyncTimeout model.Duration `yaml:"sync_timeout,omitempty"`
+	// ExcludeNodeTaints drops nodes carrying a taint with one of the listed
+	// keys from the node role and, when node metadata is attached, targets
+	// scheduled onto such nodes in the pod, endpoints and endpointslice roles.
+	ExcludeNodeTaints []string `yaml:"exclude_node_taints,omitempty"`
+	// InitialSyncGracePeriod delays the first target group send until the
+	// informer caches have fully synced or the grace period has elapsed, so
+	// that consumers do not act on a partial first batch during warm-up.
+	// Zero sends as soon as the first targets are known.
+	InitialSyncGracePeriod model.Duration `yaml:"initial_sync_grace_period,omitempty"`
+	// UpdateDebounce coalesces target group updates arriving within the
+	// window into a single batch, keeping only the latest group per source.
+	// This reduces downstream churn during mass rollouts. Zero sends every
+	// update immediately.
+	UpdateDebounce model.Duration `yaml:"update_debounce,omitempty"`
+
+	// MetricsNamespace overrides the prometheus_sd_kubernetes namespace of
+	// the discovery's own metrics, e.g. when Prometheus runs embedded in
+	// another product whose metric names would otherwise collide. It can only
+	// be set programmatically, before NewDiscovererMetrics is called; empty
+	// keeps the default names.
+	MetricsNamespace string `yaml:"-"`
+}
+
+// SelectorFromConfigMapConfig references a ConfigMap key holding a label
+// selector.
+type SelectorFromConfigMapConfig struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key"`
+}
+
+// SelectorFromCustomResourceConfig identifies a custom resource type whose
+// objects carry a label selector under spec.selector, in the manner of the
+// Prometheus Operator's PodMonitor. The selectors of all instances are
+// unioned and applied to discovery.
+type SelectorFromCustomResourceConfig struct {
+	Group    string `yaml:"group"`
+	Version  string `yaml:"version"`
+	Resource string `yaml:"resource"`
+}
+
+func (s *SelectorFromCustomResourceConfig) groupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: s.Group, Version: s.Version, Resource: s.Resource}
+}
+
+// CustomResourceConfig identifies the custom resource type watched by the
+// customresource role. AddressPath is a JSONPath expression, e.g.
+// {.spec.address}, evaluated against each object to obtain the target address.
+type CustomResourceConfig struct {
+	Group       string `yaml:"group"`
+	Version     string `yaml:"version"`
+	Resource    string `yaml:"resource"`
+	AddressPath string `yaml:"address_path"`
+}
+
+// ConfigMapTargetsConfig references a ConfigMap key holding a YAML or JSON
+// list of static target groups, in the format file-based discovery reads.
+type ConfigMapTargetsConfig struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key"`
+}
+
+func (c *CustomResourceConfig) groupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: c.Group, Version: c.Version, Resource: c.Resource}
 }
 
 // NewDiscovererMetrics implements discovery.Config.
-func (*SDConfig) NewDiscovererMetrics(reg prometheus.Registerer, rmi discovery.RefreshMetricsInstantiator) discovery.DiscovererMetrics {
-	return newDiscovererMetrics(reg, rmi)
+func (c *SDConfig) NewDiscovererMetrics(reg prometheus.Registerer, rmi discovery.RefreshMetricsInstantiator) discovery.DiscovererMetrics {
+	return newDiscovererMetrics(c.MetricsNamespace, reg, rmi)
 }
 
 // Name returns the name of the Config.
@@ -133,18 +423,31 @@ func (c *SDConfig) SetDirectory(dir string) {
 }
 
 type roleSelector struct {
-	node          resourceSelector
-	pod           resourceSelector
-	service       resourceSelector
-	endpoints     resourceSelector
-	endpointslice resourceSelector
-	ingress       resourceSelector
+	node           resourceSelector
+	pod            resourceSelector
+	service        resourceSelector
+	endpoints      resourceSelector
+	endpointslice  resourceSelector
+	ingress        resourceSelector
+	cronjob        resourceSelector
+	pvc            resourceSelector
+	customresource resourceSelector
+	serviceimport  resourceSelector
 }
 
 type SelectorConfig struct {
 	Role  Role   `yaml:"role,omitempty"`
 	Label string `yaml:"label,omitempty"`
 	Field string `yaml:"field,omitempty"`
+	// MatchLabels and MatchExpressions are a structured alternative to Label.
+	// They are converted to selector string syntax when the configuration is
+	// loaded and cannot be combined with Label.
+	MatchLabels      map[string]string                 `yaml:"match_labels,omitempty"`
+	MatchExpressions []metav1.LabelSelectorRequirement `yaml:"match_expressions,omitempty"`
+	// Namespaces restricts the selector to the listed namespaces; objects in
+	// other namespaces fall back to the role's selector without namespaces,
+	// if any. Empty keeps today's behavior of applying everywhere.
+	Namespaces []string `yaml:"namespaces,omitempty"`
 }
 
 type resourceSelector struct {
@@ -152,11 +455,57 @@ type resourceSelector struct {
 	field string
 }
 
+// LeaderElectionConfig configures how the pod role determines which pod
+// holds a leadership. Pods that do are labeled with
+// __meta_kubernetes_pod_is_leader: "true"; the label is omitted otherwise.
+type LeaderElectionConfig struct {
+	// Source of the leader identity. "lease" watches coordination.k8s.io
+	// Leases in the discovered namespaces and marks the pod named by a
+	// lease's holderIdentity (exactly or as the prefix before an underscore,
+	// the common "<pod>_<id>" convention). "annotation" marks pods carrying
+	// the configured annotation.
+	Source string `yaml:"source"`
+	// Annotation whose presence marks the leader, for the annotation source.
+	// Defaults to control-plane.alpha.kubernetes.io/leader.
+	Annotation string `yaml:"annotation,omitempty"`
+}
+
 // AttachMetadataConfig is the configuration for attaching additional metadata
-// coming from namespaces or nodes on which the targets are scheduled.
+// coming from namespaces, nodes on which the targets are scheduled or the
+// workloads owning the targets.
 type AttachMetadataConfig struct {
-	Node      bool `yaml:"node"`
-	Namespace bool `yaml:"namespace"`
+	Node          bool `yaml:"node"`
+	Namespace     bool `yaml:"namespace"`
+	OwnerWorkload bool `yaml:"owner_workload"`
+	// NamespaceLabelPrefixes restricts the namespace labels attached via
+	// Namespace to those whose name starts with one of the given prefixes,
+	// to keep label cardinality in check on clusters with many namespace
+	// labels. Empty attaches all of them. Annotations are unaffected.
+	NamespaceLabelPrefixes []string `yaml:"namespace_label_prefixes,omitempty"`
+	// NodeCapacity makes the node role attach the node's capacity and
+	// allocatable resources (cpu, memory, pods) as meta labels, normalized
+	// to base units, e.g. to relabel scrape intervals based on node size.
+	NodeCapacity bool `yaml:"node_capacity,omitempty"`
+	// IngressClass makes the ingress role watch IngressClass resources and
+	// attach the controller string of each Ingress's class, e.g. to route
+	// blackbox probes by ingress controller.
+	IngressClass bool `yaml:"ingress_class,omitempty"`
+}
+
+// knownFieldSelectors maps field selector keys that the API server only
+// indexes for specific resources. Validation of field selectors is
+// deliberately conservative: keys not listed here are passed through
+// unchecked, so new or uncommon server-side fields are not over-rejected.
+var knownFieldSelectors = map[string][]Role{
+	"spec.nodeName":           {RolePod},
+	"spec.restartPolicy":      {RolePod},
+	"spec.schedulerName":      {RolePod},
+	"spec.serviceAccountName": {RolePod},
+	"status.phase":            {RolePod},
+	"status.podIP":            {RolePod},
+	"spec.clusterIP":          {RoleService},
+	"spec.type":               {RoleService},
+	"spec.unschedulable":      {RoleNode},
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -168,7 +517,7 @@ func (c *SDConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 	if c.Role == "" {
-		return errors.New("role missing (one of: pod, service, endpoints, endpointslice, node, ingress)")
+		return errors.New("role missing (one of: pod, service, endpoints, endpointslice, node, ingress, cronjob, pvc, customresource, serviceimport, configmap, apiserver)")
 	}
 	err = c.HTTPClientConfig.Validate()
 	if err != nil {
@@ -191,33 +540,223 @@ func (c *SDConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.KubeConfig != "" && c.NamespaceDiscovery.IncludeOwnNamespace {
 		return errors.New("cannot use 'kubeconfig_file' and 'namespaces.own_namespace' simultaneously")
 	}
+	if c.ResyncPeriod != 0 && time.Duration(c.ResyncPeriod) < time.Minute {
+		return errors.New("'resync_period' must be either zero or at least one minute")
+	}
+	if c.MaxNamespaces < 0 {
+		return errors.New("'max_namespaces' must not be negative")
+	}
+	if c.KubeContext != "" && c.KubeConfig == "" {
+		return errors.New("'kube_context' requires 'kubeconfig_file' to be set")
+	}
+	if c.ImpersonateUser != "" && c.APIServer.URL == nil && c.KubeConfig == "" {
+		return errors.New("'impersonate_user' requires 'api_server' or 'kubeconfig_file' to be set")
+	}
+	if len(c.ImpersonateGroups) > 0 && c.ImpersonateUser == "" {
+		return errors.New("'impersonate_groups' requires 'impersonate_user' to be set")
+	}
+	switch c.ContentType {
+	case "", "protobuf", "json":
+	default:
+		return fmt.Errorf("unsupported content_type %q, must be \"protobuf\" or \"json\"", c.ContentType)
+	}
+	if c.DefaultMetricsPort < 0 || c.DefaultMetricsPort > 65535 {
+		return errors.New("'default_metrics_port' must be a valid port number")
+	}
+	if c.DefaultMetricsPort != 0 && c.Role != RolePod {
+		return errors.New("'default_metrics_port' is only supported for the pod role")
+	}
+	if c.PodTargetsPerPod && c.Role != RolePod {
+		return errors.New("'pod_targets_per_pod' is only supported for the pod role")
+	}
+	if c.PortAnnotation != "" && c.Role != RolePod {
+		return errors.New("'port_annotation' is only supported for the pod role")
+	}
+	if c.RequirePodIP && c.Role != RolePod {
+		return errors.New("'require_pod_ip' is only supported for the pod role")
+	}
+	if c.ExcludeTerminating && c.Role != RolePod {
+		return errors.New("'exclude_terminating' is only supported for the pod role")
+	}
+	if c.LeaderElection != nil {
+		if c.Role != RolePod {
+			return errors.New("'leader_election' is only supported for the pod role")
+		}
+		switch c.LeaderElection.Source {
+		case "lease", "annotation":
+		default:
+			return fmt.Errorf("unsupported 'leader_election.source' %q, expected \"lease\" or \"annotation\"", c.LeaderElection.Source)
+		}
+		if c.LeaderElection.Annotation != "" && c.LeaderElection.Source != "annotation" {
+			return errors.New("'leader_election.annotation' is only supported with the annotation source")
+		}
+	}
+	if c.PodTargetsPerPod && c.DefaultMetricsPort != 0 {
+		return errors.New("'pod_targets_per_pod' cannot be combined with 'default_metrics_port'")
+	}
+	if len(c.AppProtocols) > 0 && c.Role != RoleService && c.Role != RoleEndpointSlice {
+		return errors.New("'app_protocols' is only supported for the service and endpointslice roles")
+	}
+	if c.EndpointPortNamePattern != "" {
+		if c.Role != RoleEndpoint {
+			return errors.New("'endpoint_port_name_pattern' is only supported for the endpoints role")
+		}
+		if _, err := regexp.Compile("^(?:" + c.EndpointPortNamePattern + ")$"); err != nil {
+			return fmt.Errorf("invalid 'endpoint_port_name_pattern': %w", err)
+		}
+	}
+	if c.DeduplicateEndpoints && c.Role != RoleEndpointSlice {
+		return errors.New("'deduplicate_endpoints' is only supported for the endpointslice role")
+	}
+	if c.GroupByService && c.Role != RoleEndpointSlice {
+		return errors.New("'group_by_service' is only supported for the endpointslice role")
+	}
+	if len(c.AttachMetadata.NamespaceLabelPrefixes) > 0 && !c.AttachMetadata.Namespace {
+		return errors.New("'attach_metadata.namespace_label_prefixes' requires 'attach_metadata.namespace' to be enabled")
+	}
+	if c.AttachMetadata.NodeCapacity && c.Role != RoleNode {
+		return errors.New("'attach_metadata.node_capacity' is only supported for the node role")
+	}
+	if c.AttachMetadata.IngressClass && c.Role != RoleIngress {
+		return errors.New("'attach_metadata.ingress_class' is only supported for the ingress role")
+	}
+	if c.Role == RoleAPIServer {
+		if len(c.Selectors) > 0 || c.SelectorFromConfigMap != nil || c.SelectorFromCustomResource != nil {
+			return errors.New("selectors are not supported for the apiserver role: it always watches the \"kubernetes\" service")
+		}
+		if len(c.NamespaceDiscovery.Names) > 0 || c.NamespaceDiscovery.IncludeOwnNamespace {
+			return errors.New("'namespaces' cannot be set for the apiserver role: it always watches the \"default\" namespace")
+		}
+	}
+	if c.Role == RoleConfigMap {
+		if len(c.Selectors) > 0 || c.SelectorFromConfigMap != nil || c.SelectorFromCustomResource != nil {
+			return errors.New("selectors are not supported for the configmap role: it always watches the configured ConfigMap")
+		}
+		if len(c.NamespaceDiscovery.Names) > 0 || c.NamespaceDiscovery.IncludeOwnNamespace {
+			return errors.New("'namespaces' cannot be set for the configmap role: the namespace is part of 'configmap_targets'")
+		}
+	}
+	if s := c.SelectorFromConfigMap; s != nil && (s.Namespace == "" || s.Name == "" || s.Key == "") {
+		return errors.New("'selector_from_configmap' requires 'namespace', 'name' and 'key' to be set")
+	}
+	if c.Role == RoleCustomResource && c.CustomResource == nil {
+		return errors.New("'custom_resource' must be set for the customresource role")
+	}
+	if cr := c.CustomResource; cr != nil {
+		if c.Role != RoleCustomResource {
+			return errors.New("'custom_resource' is only supported for the customresource role")
+		}
+		if cr.Group == "" || cr.Version == "" || cr.Resource == "" {
+			return errors.New("'custom_resource' requires 'group', 'version' and 'resource' to be set")
+		}
+		if _, err := parseAddressPath(cr.AddressPath); err != nil {
+			return fmt.Errorf("invalid 'custom_resource' address path: %w", err)
+		}
+	}
+	if c.Role == RoleConfigMap && c.ConfigMapTargets == nil {
+		return errors.New("'configmap_targets' must be set for the configmap role")
+	}
+	if cm := c.ConfigMapTargets; cm != nil {
+		if c.Role != RoleConfigMap {
+			return errors.New("'configmap_targets' is only supported for the configmap role")
+		}
+		if cm.Namespace == "" || cm.Name == "" || cm.Key == "" {
+			return errors.New("'configmap_targets' requires 'namespace', 'name' and 'key' to be set")
+		}
+	}
+	if s := c.SelectorFromCustomResource; s != nil {
+		if s.Group == "" || s.Version == "" || s.Resource == "" {
+			return errors.New("'selector_from_custom_resource' requires 'group', 'version' and 'resource' to be set")
+		}
+		switch c.Role {
+		case RolePod, RoleEndpoint, RoleEndpointSlice:
+		default:
+			return errors.New("'selector_from_custom_resource' is only supported for the pod, endpoints and endpointslice roles")
+		}
+	}
+	if c.MaxFailures < 0 {
+		return errors.New("'max_failures' must not be negative")
+	}
+	if c.ListPageSize < 0 {
+		return errors.New("'list_page_size' must not be negative")
+	}
+	if c.MaxConcurrentInformerStart < 0 {
+		return errors.New("'max_concurrent_informer_start' must not be negative")
+	}
+	if _, err := parseNodeAddressPriority(c.NodeAddressPriority); err != nil {
+		return err
+	}
+	for _, r := range c.UserAgentSuffix {
+		if r < 0x20 || r == 0x7f {
+			return errors.New("'user_agent_suffix' must not contain control characters")
+		}
+	}
 
 	foundSelectorRoles := make(map[Role]struct{})
 	allowedSelectors := map[Role][]string{
-		RolePod:           {string(RolePod)},
-		RoleService:       {string(RoleService)},
-		RoleEndpointSlice: {string(RolePod), string(RoleService), string(RoleEndpointSlice)},
-		RoleEndpoint:      {string(RolePod), string(RoleService), string(RoleEndpoint)},
-		RoleNode:          {string(RoleNode)},
-		RoleIngress:       {string(RoleIngress)},
+		RolePod:            {string(RolePod)},
+		RoleService:        {string(RoleService)},
+		RoleEndpointSlice:  {string(RolePod), string(RoleService), string(RoleEndpointSlice)},
+		RoleEndpoint:       {string(RolePod), string(RoleService), string(RoleEndpoint)},
+		RoleNode:           {string(RoleNode)},
+		RoleIngress:        {string(RoleIngress)},
+		RoleCronJob:        {string(RoleCronJob)},
+		RolePVC:            {string(RolePVC)},
+		RoleCustomResource: {string(RoleCustomResource)},
+		RoleServiceImport:  {string(RolePod), string(RoleService), string(RoleServiceImport)},
 	}
 
-	for _, selector := range c.Selectors {
-		if _, ok := foundSelectorRoles[selector.Role]; ok {
-			return fmt.Errorf("duplicated selector role: %s", selector.Role)
+	foundSelectorNamespaces := make(map[Role]map[string]struct{})
+
+	for i, selector := range c.Selectors {
+		if len(selector.Namespaces) == 0 {
+			if _, ok := foundSelectorRoles[selector.Role]; ok {
+				return fmt.Errorf("duplicated selector role: %s", selector.Role)
+			}
+			foundSelectorRoles[selector.Role] = struct{}{}
+		} else {
+			if selector.Role == RoleNode {
+				return errors.New("'namespaces' cannot be set in a node selector: nodes are not namespaced")
+			}
+			if foundSelectorNamespaces[selector.Role] == nil {
+				foundSelectorNamespaces[selector.Role] = make(map[string]struct{})
+			}
+			for _, namespace := range selector.Namespaces {
+				if _, ok := foundSelectorNamespaces[selector.Role][namespace]; ok {
+					return fmt.Errorf("multiple %s selectors cover namespace %q", selector.Role, namespace)
+				}
+				foundSelectorNamespaces[selector.Role][namespace] = struct{}{}
+			}
 		}
-		foundSelectorRoles[selector.Role] = struct{}{}
 
 		if _, ok := allowedSelectors[c.Role]; !ok {
-			return fmt.Errorf("invalid role: %q, expecting one of: pod, service, endpoints, endpointslice, node or ingress", c.Role)
+			return fmt.Errorf("invalid role: %q, expecting one of: pod, service, endpoints, endpointslice, node, ingress, cronjob, pvc, customresource or serviceimport", c.Role)
 		}
 		if !slices.Contains(allowedSelectors[c.Role], string(selector.Role)) {
 			return fmt.Errorf("%s role supports only %s selectors", c.Role, strings.Join(allowedSelectors[c.Role], ", "))
 		}
-		_, err := fields.ParseSelector(selector.Field)
+		fieldSelector, err := fields.ParseSelector(selector.Field)
 		if err != nil {
 			return err
 		}
+		for _, req := range fieldSelector.Requirements() {
+			if roles, ok := knownFieldSelectors[req.Field]; ok && !slices.Contains(roles, selector.Role) {
+				return fmt.Errorf("field selector %q is not supported for %s resources", req.Field, selector.Role)
+			}
+		}
+		if len(selector.MatchLabels) > 0 || len(selector.MatchExpressions) > 0 {
+			if selector.Label != "" {
+				return fmt.Errorf("only one of 'label' and 'match_labels'/'match_expressions' can be set in a %s selector", selector.Role)
+			}
+			sel, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+				MatchLabels:      selector.MatchLabels,
+				MatchExpressions: selector.MatchExpressions,
+			})
+			if err != nil {
+				return fmt.Errorf("invalid %s selector: %w", selector.Role, err)
+			}
+			c.Selectors[i].Label = sel.String()
+		}
 		_, err = labels.Parse(selector.Label)
 		if err != nil {
 			return err
@@ -231,28 +770,177 @@ func (c *SDConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 type NamespaceDiscovery struct {
 	IncludeOwnNamespace bool     `yaml:"own_namespace"`
 	Names               []string `yaml:"names"`
+	// ExcludeNames discovers all namespaces except the listed ones, without
+	// having to enumerate every allowed namespace.
+	ExcludeNames []string `yaml:"exclude_names,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *NamespaceDiscovery) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = NamespaceDiscovery{}
 	type plain NamespaceDiscovery
-	return unmarshal((*plain)(c))
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.Names) > 0 && len(c.ExcludeNames) > 0 {
+		return errors.New("'names' and 'exclude_names' are mutually exclusive")
+	}
+	return nil
 }
 
 // Discovery implements the discoverer interface for discovering
 // targets from Kubernetes.
 type Discovery struct {
 	sync.RWMutex
+	// LabelHook, when set before Run is called, is invoked for every target
+	// group before it is sent, with the role, the discovered object's
+	// namespace and name, and the group's shared label set. It lets embedders
+	// inject computed labels without forking the package. The hook must not
+	// block and must have no side effects beyond mutating the given label
+	// set; it is called from the discovery goroutines.
+	LabelHook func(role Role, meta metav1.ObjectMeta, ls model.LabelSet)
+
 	client             kubernetes.Interface
 	role               Role
 	logger             *slog.Logger
 	namespaceDiscovery *NamespaceDiscovery
 	discoverers        []discovery.Discoverer
 	selectors          roleSelector
-	ownNamespace       string
-	attachMetadata     AttachMetadataConfig
-	metrics            *kubernetesMetrics
+	// namespacedSelectors holds selectors restricted to specific namespaces,
+	// keyed by selector role and namespace. They take precedence over the
+	// role-wide selectors above within their namespaces.
+	namespacedSelectors map[Role]map[string]resourceSelector
+	ownNamespace        string
+	attachMetadata      AttachMetadataConfig
+	// resyncPeriod is the informers' resync interval. It is disabled by default
+	// as resyncing just periodically resends already processed updates and
+	// distorts SD metrics, but can be enabled via resync_period to guarantee
+	// periodic reconciliation.
+	resyncPeriod        time.Duration
+	includeVolumeMounts bool
+	includeSpecHash     bool
+	splitNodeEndpoints  bool
+	nodeAddressPriority []nodeAddressPreference
+	excludeNodeTaints   []string
+	defaultMetricsPort  int
+	onlyNamedPorts      bool
+	podTargetsPerPod    bool
+	portAnnotation      string
+	requirePodIP        bool
+	excludeTerminating  bool
+	// leaderElection marks leader pods, see the SDConfig field.
+	leaderElection *LeaderElectionConfig
+	// clusterName is stamped onto every target group, see the SDConfig field.
+	clusterName string
+	// sanitizeLabelValues replaces invalid UTF-8 in emitted label values.
+	sanitizeLabelValues bool
+	// appProtocols filters service and endpointslice ports by appProtocol,
+	// see the SDConfig field.
+	appProtocols []string
+	// endpointPortNameRe filters endpoint ports by name, see the
+	// EndpointPortNamePattern field.
+	endpointPortNameRe *regexp.Regexp
+	// allowWatchBookmarks is set on every watch, see the SDConfig field.
+	allowWatchBookmarks bool
+	// deduplicateEndpoints drops endpointslice targets already covered by a
+	// sibling slice of the same service, see the SDConfig field.
+	deduplicateEndpoints bool
+	// groupByService merges all slices of a service into one target group,
+	// see the SDConfig field.
+	groupByService bool
+	// loadBalancerIngressTargets makes the service role emit extra targets
+	// for the addresses in a LoadBalancer service's ingress status.
+	loadBalancerIngressTargets bool
+	// selectorFromConfigMap, when set, overrides the role's label selector
+	// with the value read from the referenced ConfigMap key.
+	selectorFromConfigMap *SelectorFromConfigMapConfig
+	// selectorFromCustomResource, when set, gates discovery on the union of
+	// the label selectors carried by the referenced custom resources.
+	// crdSelectors holds that union for the current discovery run, and
+	// crdSelectorsKey its canonical form used to detect changes.
+	selectorFromCustomResource *SelectorFromCustomResourceConfig
+	// customResource identifies the type watched by the customresource role.
+	customResource *CustomResourceConfig
+	// configMapTargets locates the ConfigMap and data key watched by the
+	// configmap role.
+	configMapTargets *ConfigMapTargetsConfig
+	dynamicClient    dynamic.Interface
+	crdSelectors     []labels.Selector
+	crdSelectorsKey  string
+	trimObjectCache  bool
+	// maxFailures is the WATCH/LIST failure budget; once exhausted,
+	// cancelDiscovery stops all informers of this discovery.
+	maxFailures int
+	failures    int
+	// lastWatchError remembers the most recent WATCH/LIST failure so that
+	// Status can surface it to callers embedding the discoverer.
+	lastWatchError error
+	// targetCount is the total number of currently emitted targets, maintained
+	// by trackTargets alongside the targets gauge.
+	targetCount int
+	// activeSources holds the number of targets per currently emitted source,
+	// maintained by trackTargets and consulted by sweepStaleSources across
+	// discovery restarts.
+	activeSources   map[string]int
+	cancelDiscovery context.CancelFunc
+	listPageSize    int64
+	// listFromWatchCache serves initial LISTs from the API server's watch
+	// cache, see the SDConfig field.
+	listFromWatchCache bool
+	syncTimeout        time.Duration
+	// initialSyncGracePeriod delays the first send, see the SDConfig field.
+	// initialSyncDone is closed once the caches of a discovery run have
+	// synced for the first time.
+	initialSyncGracePeriod time.Duration
+	initialSyncDone        chan struct{}
+	initialSyncOnce        sync.Once
+	// updateDebounce coalesces updates, see the SDConfig field.
+	updateDebounce time.Duration
+	// informerSyncs collects the HasSynced functions of all informers started
+	// for the current discovery run, to report cache sync status as a metric.
+	informerSyncs []cache.InformerSynced
+	// roleStores collects the stores of the role's main informers, one per
+	// watched namespace, backing the Lister method.
+	roleStores []cache.Store
+	// cacheStores collects the stores of all informers started for the
+	// current discovery run with the kind of object they hold, backing the
+	// cache objects gauge.
+	cacheStores []cacheStoreRef
+	// informerWG tracks all informer goroutines, so that Run only returns
+	// once every informer has fully stopped.
+	informerWG sync.WaitGroup
+	// informerStartSem, when non-nil, is a semaphore bounding how many
+	// informers start up concurrently, see MaxConcurrentInformerStart.
+	informerStartSem chan struct{}
+	metrics          *kubernetesMetrics
+}
+
+// runInformer starts an informer in the background, tracking it in informerWG.
+// With a start semaphore configured, the informer waits for a free slot first
+// and hands it back once its cache has synced, so only a bounded number of
+// informers hit the API server with their initial LIST at the same time.
+func (d *Discovery) runInformer(ctx context.Context, inf interface {
+	Run(stopCh <-chan struct{})
+	HasSynced() bool
+}) {
+	d.informerWG.Add(1)
+	go func() {
+		defer d.informerWG.Done()
+		if d.informerStartSem != nil {
+			select {
+			case d.informerStartSem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			d.informerWG.Add(1)
+			go func() {
+				defer d.informerWG.Done()
+				cache.WaitForCacheSync(ctx.Done(), inf.HasSynced)
+				<-d.informerStartSem
+			}()
+		}
+		inf.Run(ctx.Done())
+	}()
 }
 
 func (d *Discovery) getNamespaces() []string {
@@ -263,7 +951,9 @@ func (d *Discovery) getNamespaces() []string {
 		return []string{apiv1.NamespaceAll}
 	}
 
-	if includeOwnNamespace && d.ownNamespace != "" {
+	// Deduplicate, as running several informers for the same namespace would
+	// emit every matching object more than once.
+	if includeOwnNamespace && d.ownNamespace != "" && !slices.Contains(namespaces, d.ownNamespace) {
 		return append(namespaces, d.ownNamespace)
 	}
 
@@ -276,10 +966,35 @@ func New(l *slog.Logger, metrics discovery.DiscovererMetrics, conf *SDConfig) (*
 	if !ok {
 		return nil, errors.New("invalid discovery metrics type")
 	}
-
 	if l == nil {
 		l = promslog.NewNopLogger()
 	}
+	return newDiscovery(l, m, conf)
+}
+
+// ClientConfigError is returned by New when no usable client configuration
+// could be assembled, i.e. the kubeconfig file failed to load or, with
+// neither api_server nor kubeconfig_file set, the in-cluster configuration
+// was unavailable. It wraps the underlying client-go error.
+type ClientConfigError struct {
+	// KubeConfig is the configured kubeconfig file path, empty when the
+	// in-cluster configuration was attempted.
+	KubeConfig string
+	Err        error
+}
+
+func (e *ClientConfigError) Error() string {
+	if e.KubeConfig != "" {
+		return fmt.Sprintf("cannot load kubeconfig %q: %v", e.KubeConfig, e.Err)
+	}
+	return fmt.Sprintf("no APIServer/kubeconfig set and not running in-cluster: %v", e.Err)
+}
+
+func (e *ClientConfigError) Unwrap() error { return e.Err }
+
+// buildRestConfig assembles the client-go rest config for the SD configuration
+// and resolves the pod's own namespace when requested.
+func buildRestConfig(l *slog.Logger, conf *SDConfig) (*rest.Config, string, error) {
 	var (
 		kcfg         *rest.Config
 		err          error
@@ -287,59 +1002,159 @@ func New(l *slog.Logger, metrics discovery.DiscovererMetrics, conf *SDConfig) (*
 	)
 	switch {
 	case conf.KubeConfig != "":
-		kcfg, err = clientcmd.BuildConfigFromFlags("", conf.KubeConfig)
+		kcfg, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: conf.KubeConfig},
+			&clientcmd.ConfigOverrides{CurrentContext: conf.KubeContext},
+		).ClientConfig()
 		if err != nil {
-			return nil, err
+			return nil, "", &ClientConfigError{KubeConfig: conf.KubeConfig, Err: err}
 		}
 	case conf.APIServer.URL == nil:
 		// Use the Kubernetes provided pod service account
 		// as described in https://kubernetes.io/docs/tasks/run-application/access-api-from-pod/#using-official-client-libraries
 		kcfg, err = rest.InClusterConfig()
 		if err != nil {
-			return nil, err
+			return nil, "", &ClientConfigError{Err: err}
 		}
 
 		if conf.NamespaceDiscovery.IncludeOwnNamespace {
 			ownNamespaceContents, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
 			if err != nil {
-				return nil, fmt.Errorf("could not determine the pod's namespace: %w", err)
+				return nil, "", fmt.Errorf("could not determine the pod's namespace: %w", err)
 			}
 			if len(ownNamespaceContents) == 0 {
-				return nil, errors.New("could not read own namespace name (empty file)")
+				return nil, "", errors.New("could not read own namespace name (empty file)")
 			}
 			ownNamespace = string(ownNamespaceContents)
 		}
 
 		l.Info("Using pod service account via in-cluster config")
 	default:
-		rt, err := config.NewRoundTripperFromConfig(conf.HTTPClientConfig, "kubernetes_sd")
+		httpCfg := conf.HTTPClientConfig
+		var bearerTokenFile string
+		// A bearer token file is handed to client-go instead of being baked
+		// into the round tripper: its transport re-reads the file, so rotated
+		// tokens are picked up without a restart.
+		if auth := httpCfg.Authorization; auth != nil && auth.CredentialsFile != "" &&
+			(auth.Type == "" || strings.EqualFold(auth.Type, "Bearer")) {
+			bearerTokenFile = auth.CredentialsFile
+			httpCfg.Authorization = nil
+		}
+		rt, err := config.NewRoundTripperFromConfig(httpCfg, "kubernetes_sd")
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		kcfg = &rest.Config{
-			Host:      conf.APIServer.String(),
-			Transport: rt,
+			Host:            conf.APIServer.String(),
+			Transport:       rt,
+			BearerTokenFile: bearerTokenFile,
+		}
+	}
+
+	if conf.ImpersonateUser != "" {
+		kcfg.Impersonate = rest.ImpersonationConfig{
+			UserName: conf.ImpersonateUser,
+			Groups:   conf.ImpersonateGroups,
 		}
 	}
 
 	kcfg.UserAgent = version.PrometheusUserAgent()
-	kcfg.ContentType = "application/vnd.kubernetes.protobuf"
+	if conf.UserAgentSuffix != "" {
+		kcfg.UserAgent += " " + conf.UserAgentSuffix
+	}
+	if conf.ContentType == "json" {
+		kcfg.ContentType = "application/json"
+	} else {
+		kcfg.ContentType = "application/vnd.kubernetes.protobuf"
+	}
+
+	return kcfg, ownNamespace, nil
+}
+
+func newDiscovery(l *slog.Logger, m *kubernetesMetrics, conf *SDConfig) (*Discovery, error) {
+	kcfg, ownNamespace, err := buildRestConfig(l, conf)
+	if err != nil {
+		return nil, err
+	}
 
 	c, err := kubernetes.NewForConfig(kcfg)
 	if err != nil {
 		return nil, err
 	}
 
+	nodeAddressPriority, err := parseNodeAddressPriority(conf.NodeAddressPriority)
+	if err != nil {
+		return nil, err
+	}
+
+	var dyn dynamic.Interface
+	if conf.SelectorFromCustomResource != nil || conf.CustomResource != nil || conf.Role == RoleServiceImport {
+		// The dynamic client copies the config and forces JSON content type.
+		dyn, err = dynamic.NewForConfig(kcfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	d := &Discovery{
-		client:             c,
-		logger:             l,
-		role:               conf.Role,
-		namespaceDiscovery: &conf.NamespaceDiscovery,
-		discoverers:        make([]discovery.Discoverer, 0),
-		selectors:          mapSelector(conf.Selectors),
-		ownNamespace:       ownNamespace,
-		attachMetadata:     conf.AttachMetadata,
-		metrics:            m,
+		client:                     c,
+		dynamicClient:              dyn,
+		logger:                     l,
+		role:                       conf.Role,
+		namespaceDiscovery:         &conf.NamespaceDiscovery,
+		discoverers:                make([]discovery.Discoverer, 0),
+		selectors:                  mapSelector(conf.Selectors),
+		namespacedSelectors:        mapNamespacedSelectors(conf.Selectors),
+		ownNamespace:               ownNamespace,
+		attachMetadata:             conf.AttachMetadata,
+		resyncPeriod:               time.Duration(conf.ResyncPeriod),
+		includeVolumeMounts:        conf.IncludeVolumeMounts,
+		includeSpecHash:            conf.IncludeSpecHash,
+		splitNodeEndpoints:         conf.SplitNodeEndpoints,
+		nodeAddressPriority:        nodeAddressPriority,
+		excludeNodeTaints:          conf.ExcludeNodeTaints,
+		defaultMetricsPort:         conf.DefaultMetricsPort,
+		onlyNamedPorts:             conf.OnlyNamedPorts,
+		podTargetsPerPod:           conf.PodTargetsPerPod,
+		portAnnotation:             conf.PortAnnotation,
+		requirePodIP:               conf.RequirePodIP,
+		excludeTerminating:         conf.ExcludeTerminating,
+		leaderElection:             conf.LeaderElection,
+		clusterName:                conf.ClusterName,
+		sanitizeLabelValues:        conf.SanitizeLabelValues,
+		appProtocols:               conf.AppProtocols,
+		allowWatchBookmarks:        conf.AllowWatchBookmarks,
+		deduplicateEndpoints:       conf.DeduplicateEndpoints,
+		groupByService:             conf.GroupByService,
+		loadBalancerIngressTargets: conf.LoadBalancerIngressTargets,
+		selectorFromConfigMap:      conf.SelectorFromConfigMap,
+		selectorFromCustomResource: conf.SelectorFromCustomResource,
+		customResource:             conf.CustomResource,
+		configMapTargets:           conf.ConfigMapTargets,
+		trimObjectCache:            conf.TrimObjectCache,
+		maxFailures:                conf.MaxFailures,
+		listPageSize:               conf.ListPageSize,
+		listFromWatchCache:         conf.ListFromWatchCache,
+		syncTimeout:                time.Duration(conf.SyncTimeout),
+		initialSyncGracePeriod:     time.Duration(conf.InitialSyncGracePeriod),
+		initialSyncDone:            make(chan struct{}),
+		updateDebounce:             time.Duration(conf.UpdateDebounce),
+		activeSources:              map[string]int{},
+		metrics:                    m,
+	}
+
+	if conf.EndpointPortNamePattern != "" {
+		// Validated during unmarshaling.
+		d.endpointPortNameRe = regexp.MustCompile("^(?:" + conf.EndpointPortNamePattern + ")$")
+	}
+	if conf.MaxConcurrentInformerStart > 0 {
+		d.informerStartSem = make(chan struct{}, conf.MaxConcurrentInformerStart)
+	}
+
+	if conf.MaxNamespaces > 0 {
+		if n := len(d.getNamespaces()); n > conf.MaxNamespaces {
+			return nil, fmt.Errorf("%d namespaces resolved for discovery, exceeding 'max_namespaces' (%d); consider cluster-scoped discovery with label or field selectors instead", n, conf.MaxNamespaces)
+		}
 	}
 
 	return d, nil
@@ -348,6 +1163,11 @@ func New(l *slog.Logger, metrics discovery.DiscovererMetrics, conf *SDConfig) (*
 func mapSelector(rawSelector []SelectorConfig) roleSelector {
 	rs := roleSelector{}
 	for _, resourceSelectorRaw := range rawSelector {
+		if len(resourceSelectorRaw.Namespaces) > 0 {
+			// Namespace-scoped selectors are kept separately, see
+			// mapNamespacedSelectors.
+			continue
+		}
 		switch resourceSelectorRaw.Role {
 		case RoleEndpointSlice:
 			rs.endpointslice.field = resourceSelectorRaw.Field
@@ -367,209 +1187,1324 @@ func mapSelector(rawSelector []SelectorConfig) roleSelector {
 		case RoleService:
 			rs.service.field = resourceSelectorRaw.Field
 			rs.service.label = resourceSelectorRaw.Label
+		case RoleCronJob:
+			rs.cronjob.field = resourceSelectorRaw.Field
+			rs.cronjob.label = resourceSelectorRaw.Label
+		case RolePVC:
+			rs.pvc.field = resourceSelectorRaw.Field
+			rs.pvc.label = resourceSelectorRaw.Label
+		case RoleCustomResource:
+			rs.customresource.field = resourceSelectorRaw.Field
+			rs.customresource.label = resourceSelectorRaw.Label
+		case RoleServiceImport:
+			rs.serviceimport.field = resourceSelectorRaw.Field
+			rs.serviceimport.label = resourceSelectorRaw.Label
+		}
+	}
+	return rs
+}
+
+func mapNamespacedSelectors(rawSelector []SelectorConfig) map[Role]map[string]resourceSelector {
+	var m map[Role]map[string]resourceSelector
+	for _, resourceSelectorRaw := range rawSelector {
+		if len(resourceSelectorRaw.Namespaces) == 0 {
+			continue
+		}
+		if m == nil {
+			m = make(map[Role]map[string]resourceSelector)
+		}
+		if m[resourceSelectorRaw.Role] == nil {
+			m[resourceSelectorRaw.Role] = make(map[string]resourceSelector)
+		}
+		for _, namespace := range resourceSelectorRaw.Namespaces {
+			m[resourceSelectorRaw.Role][namespace] = resourceSelector{
+				label: resourceSelectorRaw.Label,
+				field: resourceSelectorRaw.Field,
+			}
+		}
+	}
+	return m
+}
+
+// namespaceSelectors returns the selectors effective in the given namespace:
+// selectors scoped to that namespace take precedence over the role-wide ones.
+func (d *Discovery) namespaceSelectors(namespace string) roleSelector {
+	rs := d.selectors
+	for role, byNamespace := range d.namespacedSelectors {
+		sel, ok := byNamespace[namespace]
+		if !ok {
+			continue
+		}
+		switch role {
+		case RoleEndpointSlice:
+			rs.endpointslice = sel
+		case RoleEndpoint:
+			rs.endpoints = sel
+		case RoleIngress:
+			rs.ingress = sel
+		case RolePod:
+			rs.pod = sel
+		case RoleService:
+			rs.service = sel
+		case RoleCronJob:
+			rs.cronjob = sel
+		case RolePVC:
+			rs.pvc = sel
+		case RoleCustomResource:
+			rs.customresource = sel
+		case RoleServiceImport:
+			rs.serviceimport = sel
+		}
+	}
+	return rs
+}
+
+// DiscoveryStatus is a point-in-time snapshot of a Discovery's health. It
+// exposes the state already tracked for the SD metrics to callers that embed
+// the discoverer via the discovery.Discoverer interface and cannot scrape
+// them.
+type DiscoveryStatus struct {
+	// Role is the role the discovery was configured with.
+	Role Role
+	// Synced reports whether all informers of the current discovery run have
+	// synced their caches. It is false before Run has started any informers.
+	Synced bool
+	// Failures is the number of WATCH/LIST failures observed so far.
+	Failures int
+	// LastWatchError is the most recent WATCH/LIST failure, or nil if none
+	// occurred.
+	LastWatchError error
+	// TargetCount is the total number of targets currently emitted across all
+	// target groups.
+	TargetCount int
+}
+
+// Status returns a snapshot of the discovery's current state.
+func (d *Discovery) Status() DiscoveryStatus {
+	d.RLock()
+	defer d.RUnlock()
+	status := DiscoveryStatus{
+		Role:           d.role,
+		Synced:         len(d.informerSyncs) > 0,
+		Failures:       d.failures,
+		LastWatchError: d.lastWatchError,
+		TargetCount:    d.targetCount,
+	}
+	for _, synced := range d.informerSyncs {
+		if !synced() {
+			status.Synced = false
+			break
+		}
+	}
+	return status
+}
+
+// Lister returns a point-in-time read interface over the objects currently
+// held in the informer caches for the given role, so embedders can answer
+// "what does discovery see right now" without consuming the update channel.
+// It reuses the informers created by Run: an error is returned before Run has
+// started them or when role does not match the configured role.
+func (d *Discovery) Lister(role Role) (cache.GenericLister, error) {
+	if role != d.role {
+		return nil, fmt.Errorf("discovery is configured for role %q, not %q", d.role, role)
+	}
+	d.RLock()
+	defer d.RUnlock()
+	if len(d.roleStores) == 0 {
+		return nil, errors.New("no informers are running: Run has not been started")
+	}
+	return &storeLister{stores: slices.Clone(d.roleStores), resource: d.roleGroupResource()}, nil
+}
+
+// roleGroupResource maps the configured role to the group and resource of the
+// objects it watches, for lister not-found errors.
+func (d *Discovery) roleGroupResource() schema.GroupResource {
+	switch d.role {
+	case RoleNode:
+		return schema.GroupResource{Resource: "nodes"}
+	case RolePod:
+		return schema.GroupResource{Resource: "pods"}
+	case RoleService:
+		return schema.GroupResource{Resource: "services"}
+	case RoleEndpoint, RoleAPIServer:
+		return schema.GroupResource{Resource: "endpoints"}
+	case RoleEndpointSlice, RoleServiceImport:
+		return schema.GroupResource{Group: "discovery.k8s.io", Resource: "endpointslices"}
+	case RoleIngress:
+		return schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}
+	case RoleCronJob:
+		return schema.GroupResource{Group: "batch", Resource: "cronjobs"}
+	case RolePVC:
+		return schema.GroupResource{Resource: "persistentvolumeclaims"}
+	case RoleCustomResource:
+		return d.customResource.groupVersionResource().GroupResource()
+	case RoleConfigMap:
+		return schema.GroupResource{Resource: "configmaps"}
+	}
+	return schema.GroupResource{}
+}
+
+// storeLister exposes the informer stores of one discovery run as a single
+// read-only lister. A run may hold several stores, one per watched namespace;
+// the namespaces never overlap, so concatenating their contents is safe.
+type storeLister struct {
+	stores   []cache.Store
+	resource schema.GroupResource
+}
+
+func (s *storeLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	var all []runtime.Object
+	for _, store := range s.stores {
+		for _, o := range store.List() {
+			obj, ok := o.(runtime.Object)
+			if !ok {
+				continue
+			}
+			acc, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, err
+			}
+			if selector != nil && !selector.Matches(labels.Set(acc.GetLabels())) {
+				continue
+			}
+			all = append(all, obj)
+		}
+	}
+	return all, nil
+}
+
+// Get looks up an object by its store key, i.e. "<namespace>/<name>" for
+// namespaced resources and the plain name for cluster-scoped ones.
+func (s *storeLister) Get(name string) (runtime.Object, error) {
+	for _, store := range s.stores {
+		o, exists, err := store.GetByKey(name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return o.(runtime.Object), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(s.resource, name)
+}
+
+func (s *storeLister) ByNamespace(namespace string) cache.GenericNamespaceLister {
+	return &storeNamespaceLister{lister: s, namespace: namespace}
+}
+
+type storeNamespaceLister struct {
+	lister    *storeLister
+	namespace string
+}
+
+func (s *storeNamespaceLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	objs, err := s.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	var filtered []runtime.Object
+	for _, obj := range objs {
+		acc, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if acc.GetNamespace() == s.namespace {
+			filtered = append(filtered, obj)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *storeNamespaceLister) Get(name string) (runtime.Object, error) {
+	return s.lister.Get(s.namespace + "/" + name)
+}
+
+// Run implements the discoverer interface.
+func (d *Discovery) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
+	if d.maxFailures > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		d.Lock()
+		d.cancelDiscovery = cancel
+		d.Unlock()
+	}
+	if d.selectorFromCustomResource != nil && !d.checkCustomResourceServed(ctx) {
+		return
+	}
+	ch = d.trackTargets(ctx, ch)
+	if d.sanitizeLabelValues {
+		ch = d.sanitizeInvalidLabelValues(ctx, ch)
+	}
+	if d.LabelHook != nil {
+		ch = d.applyLabelHook(ctx, ch)
+	}
+	if d.clusterName != "" {
+		ch = d.attachClusterName(ctx, ch)
+	}
+	if d.initialSyncGracePeriod > 0 {
+		ch = d.delayInitialSend(ctx, ch)
+	}
+	if d.updateDebounce > 0 {
+		ch = d.debounceUpdates(ctx, ch)
+	}
+	if d.selectorFromConfigMap == nil && d.selectorFromCustomResource == nil {
+		d.runDiscoverers(ctx, ch)
+		return
+	}
+	// Restart discovery from scratch whenever a dynamic selector source
+	// changes, so that the informers relist with the new selectors applied.
+	for ctx.Err() == nil {
+		runCtx, cancel := context.WithCancel(ctx)
+		if d.selectorFromConfigMap != nil {
+			d.reloadSelectorConfigMap(runCtx)
+			d.watchSelectorConfigMap(runCtx, cancel)
+		}
+		if d.selectorFromCustomResource != nil {
+			d.reloadCustomResourceSelectors(runCtx)
+			d.watchCustomResourceSelectors(runCtx, cancel)
+		}
+		d.runDiscoverers(runCtx, ch)
+		cancel()
+	}
+}
+
+// trackTargets forwards target groups to ch unchanged while maintaining the
+// targets gauge with the total number of targets currently emitted for the
+// role, and the activeSources map behind it. Groups that become empty, e.g.
+// on object deletion, reduce the total and drop their source.
+func (d *Discovery) trackTargets(ctx context.Context, ch chan<- []*targetgroup.Group) chan<- []*targetgroup.Group {
+	in := make(chan []*targetgroup.Group)
+	gauge := d.metrics.targetsCount.WithLabelValues(d.role.String())
+	go func() {
+		total := 0
+		for {
+			var tgs []*targetgroup.Group
+			select {
+			case <-ctx.Done():
+				return
+			case tgs = <-in:
+			}
+			d.Lock()
+			for _, tg := range tgs {
+				n := len(tg.Targets)
+				total += n - d.activeSources[tg.Source]
+				if n == 0 {
+					delete(d.activeSources, tg.Source)
+				} else {
+					d.activeSources[tg.Source] = n
+				}
+			}
+			d.targetCount = total
+			d.Unlock()
+			gauge.Set(float64(total))
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- tgs:
+			}
+		}
+	}()
+	return in
+}
+
+// sweepStaleSources clears the sources that were emitted before a discovery
+// restart but whose objects are no longer present in the restarted run's
+// informer caches, e.g. targets de-selected by a narrowed dynamic selector.
+// The new run's informers never emit groups for such sources, so without the
+// sweep their targets would persist downstream until a process restart.
+func (d *Discovery) sweepStaleSources(ctx context.Context, ch chan<- []*targetgroup.Group) {
+	d.RLock()
+	sources := make([]string, 0, len(d.activeSources))
+	for source := range d.activeSources {
+		sources = append(sources, source)
+	}
+	stores := slices.Clone(d.cacheStores)
+	d.RUnlock()
+
+	var stale []*targetgroup.Group
+	for _, source := range sources {
+		meta := sourceObjectMeta(source)
+		if meta.Name == "" {
+			continue
+		}
+		key := meta.Name
+		if meta.Namespace != "" {
+			key = meta.Namespace + "/" + meta.Name
+		}
+		// Sources whose object still exists in any cache are left alone: the
+		// new run re-emits them, and clearing them here could race with that.
+		found := false
+		for _, ref := range stores {
+			if _, exists, err := ref.store.GetByKey(key); err == nil && exists {
+				found = true
+				break
+			}
+		}
+		if !found {
+			stale = append(stale, &targetgroup.Group{Source: source})
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case ch <- stale:
+	}
+}
+
+// sourceObjectMeta reconstructs the discovered object's namespace and name
+// from a target group source, which is derived from exactly those two (see
+// the Role documentation for the per-role formats).
+func sourceObjectMeta(source string) metav1.ObjectMeta {
+	parts := strings.Split(source, "/")
+	switch len(parts) {
+	case 2: // Cluster-scoped, e.g. node/<name>.
+		return metav1.ObjectMeta{Name: parts[1]}
+	case 3:
+		return metav1.ObjectMeta{Namespace: parts[1], Name: parts[2]}
+	case 4:
+		// With group_by_service, endpointslice/<namespace>/service/<service>
+		// groups are keyed by the owning service.
+		if parts[0] == "endpointslice" && parts[2] == "service" {
+			return metav1.ObjectMeta{Namespace: parts[1], Name: parts[3]}
+		}
+		// configmap/<namespace>/<name>/<index>.
+		return metav1.ObjectMeta{Namespace: parts[1], Name: parts[2]}
+	}
+	return metav1.ObjectMeta{}
+}
+
+// applyLabelHook runs the LabelHook on every non-empty target group passing
+// through, before the remaining wrappers and the downstream consumer see it.
+func (d *Discovery) applyLabelHook(ctx context.Context, ch chan<- []*targetgroup.Group) chan<- []*targetgroup.Group {
+	in := make(chan []*targetgroup.Group)
+	go func() {
+		for {
+			var tgs []*targetgroup.Group
+			select {
+			case <-ctx.Done():
+				return
+			case tgs = <-in:
+			}
+			for _, tg := range tgs {
+				if tg == nil || len(tg.Targets) == 0 {
+					continue
+				}
+				if tg.Labels == nil {
+					tg.Labels = model.LabelSet{}
+				}
+				d.LabelHook(d.role, sourceObjectMeta(tg.Source), tg.Labels)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- tgs:
+			}
+		}
+	}()
+	return in
+}
+
+// sanitizeInvalidLabelValues replaces invalid UTF-8 sequences in label values
+// of passing target groups with the Unicode replacement character, logging a
+// warning naming the offending label.
+func (d *Discovery) sanitizeInvalidLabelValues(ctx context.Context, ch chan<- []*targetgroup.Group) chan<- []*targetgroup.Group {
+	in := make(chan []*targetgroup.Group)
+	go func() {
+		for {
+			var tgs []*targetgroup.Group
+			select {
+			case <-ctx.Done():
+				return
+			case tgs = <-in:
+			}
+			for _, tg := range tgs {
+				if tg == nil {
+					continue
+				}
+				d.sanitizeLabelSet(tg.Labels, tg.Source)
+				for _, target := range tg.Targets {
+					d.sanitizeLabelSet(target, tg.Source)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- tgs:
+			}
+		}
+	}()
+	return in
+}
+
+// sanitizeLabelSet rewrites label values that are not valid UTF-8 in place.
+func (d *Discovery) sanitizeLabelSet(labelSet model.LabelSet, source string) {
+	for name, value := range labelSet {
+		if utf8.ValidString(string(value)) {
+			continue
+		}
+		labelSet[name] = model.LabelValue(strings.ToValidUTF8(string(value), "\ufffd"))
+		d.logger.Warn("Replaced invalid UTF-8 in label value", "label", string(name), "source", source)
+	}
+}
+
+// attachClusterName stamps every passing target group with the configured
+// cluster name label. Groups that became empty, e.g. on object deletion, are
+// forwarded untouched.
+func (d *Discovery) attachClusterName(ctx context.Context, ch chan<- []*targetgroup.Group) chan<- []*targetgroup.Group {
+	in := make(chan []*targetgroup.Group)
+	name := lv(d.clusterName)
+	go func() {
+		for {
+			var tgs []*targetgroup.Group
+			select {
+			case <-ctx.Done():
+				return
+			case tgs = <-in:
+			}
+			for _, tg := range tgs {
+				if tg == nil || len(tg.Targets) == 0 {
+					continue
+				}
+				if tg.Labels == nil {
+					tg.Labels = model.LabelSet{}
+				}
+				tg.Labels[clusterNameLabel] = name
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- tgs:
+			}
+		}
+	}()
+	return in
+}
+
+// delayInitialSend buffers target groups until the informer caches have fully
+// synced or the grace period has elapsed, whichever comes first, and then
+// forwards transparently. This avoids emitting a partial first batch while
+// discovery is still warming up, which would make downstream consumers treat
+// not-yet-discovered targets as gone.
+func (d *Discovery) delayInitialSend(ctx context.Context, ch chan<- []*targetgroup.Group) chan<- []*targetgroup.Group {
+	in := make(chan []*targetgroup.Group)
+	go func() {
+		grace := time.NewTimer(d.initialSyncGracePeriod)
+		defer grace.Stop()
+		var (
+			order   []string
+			pending = map[string]*targetgroup.Group{}
+		)
+		buffering := true
+		for buffering {
+			select {
+			case <-ctx.Done():
+				return
+			case tgs := <-in:
+				// Keep only the latest group per source, in arrival order.
+				for _, tg := range tgs {
+					if _, ok := pending[tg.Source]; !ok {
+						order = append(order, tg.Source)
+					}
+					pending[tg.Source] = tg
+				}
+			case <-d.initialSyncDone:
+				buffering = false
+			case <-grace.C:
+				buffering = false
+			}
+		}
+		if len(order) > 0 {
+			flush := make([]*targetgroup.Group, 0, len(order))
+			for _, source := range order {
+				flush = append(flush, pending[source])
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- flush:
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case tgs := <-in:
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- tgs:
+				}
+			}
+		}
+	}()
+	return in
+}
+
+// debounceUpdates holds back target groups for up to the debounce window and
+// coalesces them into one batch, keeping only the latest group per source.
+// A group that became empty, e.g. on object deletion, is a regular update
+// and survives coalescing. The window starts with the first update after an
+// idle period, so a single update is delayed by at most the full window.
+func (d *Discovery) debounceUpdates(ctx context.Context, ch chan<- []*targetgroup.Group) chan<- []*targetgroup.Group {
+	in := make(chan []*targetgroup.Group)
+	go func() {
+		var (
+			window  *time.Timer
+			windowC <-chan time.Time
+			order   []string
+			pending = map[string]*targetgroup.Group{}
+		)
+		defer func() {
+			if window != nil {
+				window.Stop()
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case tgs := <-in:
+				for _, tg := range tgs {
+					if _, ok := pending[tg.Source]; !ok {
+						order = append(order, tg.Source)
+					}
+					pending[tg.Source] = tg
+				}
+				if windowC == nil {
+					window = time.NewTimer(d.updateDebounce)
+					windowC = window.C
+				}
+			case <-windowC:
+				flush := make([]*targetgroup.Group, 0, len(order))
+				for _, source := range order {
+					flush = append(flush, pending[source])
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- flush:
+				}
+				order = order[:0]
+				clear(pending)
+				windowC = nil
+			}
+		}
+	}()
+	return in
+}
+
+// reloadSelectorConfigMap reads the referenced ConfigMap and applies the label
+// selector it holds to the configured role. It is only called between
+// discovery runs, while no informer is listing or watching with the selector.
+func (d *Discovery) reloadSelectorConfigMap(ctx context.Context) {
+	ref := d.selectorFromConfigMap
+	cm, err := d.client.CoreV1().ConfigMaps(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		d.logger.Error("Unable to read selector ConfigMap", "namespace", ref.Namespace, "configmap", ref.Name, "err", err)
+		return
+	}
+	label := cm.Data[ref.Key]
+	if _, err := labels.Parse(label); err != nil {
+		d.logger.Error("Invalid label selector in ConfigMap", "selector", label, "err", err)
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+	if sel := d.roleSelector(); sel != nil {
+		sel.label = label
+	}
+}
+
+// watchSelectorConfigMap arranges for discovery to be restarted when the
+// referenced ConfigMap no longer matches the applied selector.
+func (d *Discovery) watchSelectorConfigMap(ctx context.Context, restart context.CancelFunc) {
+	ref := d.selectorFromConfigMap
+	cms := d.client.CoreV1().ConfigMaps(ref.Namespace)
+
+	clw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = "metadata.name=" + ref.Name
+			return cms.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
+			options.FieldSelector = "metadata.name=" + ref.Name
+			return cms.Watch(ctx, options)
+		},
+	}
+	informer := d.mustNewUnregisteredSharedInformer(clw, &apiv1.ConfigMap{})
+	handleUpdate := func(o interface{}) {
+		cm, ok := o.(*apiv1.ConfigMap)
+		if !ok || cm.Name != ref.Name {
+			return
+		}
+		label := cm.Data[ref.Key]
+		if _, err := labels.Parse(label); err != nil {
+			d.logger.Error("Invalid label selector in ConfigMap", "selector", label, "err", err)
+			return
+		}
+		d.RLock()
+		sel := d.roleSelector()
+		changed := sel != nil && sel.label != label
+		d.RUnlock()
+		if changed {
+			d.logger.Info("Selector ConfigMap changed, restarting discovery", "namespace", ref.Namespace, "configmap", ref.Name)
+			restart()
+		}
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handleUpdate,
+		UpdateFunc: func(_, o interface{}) { handleUpdate(o) },
+	})
+	if err != nil {
+		d.logger.Error("Error adding selector ConfigMap event handler.", "err", err)
+	}
+	d.runInformer(ctx, informer)
+}
+
+func (d *Discovery) roleSelector() *resourceSelector {
+	switch d.role {
+	case RoleNode:
+		return &d.selectors.node
+	case RolePod:
+		return &d.selectors.pod
+	case RoleService:
+		return &d.selectors.service
+	case RoleEndpoint:
+		return &d.selectors.endpoints
+	case RoleEndpointSlice:
+		return &d.selectors.endpointslice
+	case RoleIngress:
+		return &d.selectors.ingress
+	case RoleCronJob:
+		return &d.selectors.cronjob
+	case RolePVC:
+		return &d.selectors.pvc
+	case RoleCustomResource:
+		return &d.selectors.customresource
+	case RoleServiceImport:
+		return &d.selectors.serviceimport
+	}
+	return nil
+}
+
+// checkCustomResourceServed verifies that the referenced custom resource type
+// is served by the API server before discovery starts, so that a typo in the
+// configuration surfaces as an error instead of silently discovering nothing.
+// Only a definitive answer that the type is not served stops discovery;
+// transient failures of the discovery call itself are retried so that e.g. an
+// API server hiccup at startup does not permanently disable discovery.
+func (d *Discovery) checkCustomResourceServed(ctx context.Context) bool {
+	ref := d.selectorFromCustomResource
+	gv := schema.GroupVersion{Group: ref.Group, Version: ref.Version}
+	served := false
+	canceled := retryOnError(ctx, 10*time.Second, func() error {
+		rl, err := d.client.Discovery().ServerResourcesForGroupVersion(gv.String())
+		switch {
+		case apierrors.IsNotFound(err):
+			// Definitive: the group/version itself is not served.
+			d.logger.Error("Selector custom resource group/version is not served by the API server", "groupversion", gv.String())
+			return nil
+		case err != nil:
+			d.logger.Error("Unable to verify that the selector custom resource is served, retrying", "groupversion", gv.String(), "err", err)
+			return err
+		}
+		for _, r := range rl.APIResources {
+			if r.Name == ref.Resource {
+				served = true
+				return nil
+			}
+		}
+		d.logger.Error("Selector custom resource is not served by the API server", "groupversion", gv.String(), "resource", ref.Resource)
+		return nil
+	})
+	return !canceled && served
+}
+
+// reloadCustomResourceSelectors reads all instances of the referenced custom
+// resource and stores the union of their label selectors. It is only called
+// between discovery runs, while no informer is gated on the selectors.
+func (d *Discovery) reloadCustomResourceSelectors(ctx context.Context) {
+	sels, key, err := d.listCustomResourceSelectors(ctx)
+	if err != nil {
+		d.logger.Error("Unable to list selector custom resources", "err", err)
+		return
+	}
+	d.Lock()
+	d.crdSelectors = sels
+	d.crdSelectorsKey = key
+	d.Unlock()
+}
+
+// watchCustomResourceSelectors arranges for discovery to be restarted when the
+// union of selectors carried by the custom resources no longer matches the
+// applied one.
+func (d *Discovery) watchCustomResourceSelectors(ctx context.Context, restart context.CancelFunc) {
+	res := d.dynamicClient.Resource(d.selectorFromCustomResource.groupVersionResource()).Namespace(apiv1.NamespaceAll)
+	clw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return res.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
+			return res.Watch(ctx, options)
+		},
+	}
+	informer := d.mustNewUnregisteredSharedInformer(clw, &unstructured.Unstructured{})
+	check := func(interface{}) {
+		_, key, err := d.listCustomResourceSelectors(ctx)
+		if err != nil {
+			d.logger.Error("Unable to list selector custom resources", "err", err)
+			return
+		}
+		d.RLock()
+		changed := key != d.crdSelectorsKey
+		d.RUnlock()
+		if changed {
+			d.logger.Info("Custom resource selectors changed, restarting discovery", "resource", d.selectorFromCustomResource.Resource)
+			restart()
+		}
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    check,
+		UpdateFunc: func(_, o interface{}) { check(o) },
+		DeleteFunc: check,
+	})
+	if err != nil {
+		d.logger.Error("Error adding selector custom resource event handler.", "err", err)
+	}
+	d.runInformer(ctx, informer)
+}
+
+// listCustomResourceSelectors returns the label selectors carried by all
+// instances of the referenced custom resource, along with a canonical string
+// form of the union used to detect changes. Instances without a valid
+// spec.selector are skipped.
+func (d *Discovery) listCustomResourceSelectors(ctx context.Context) ([]labels.Selector, string, error) {
+	list, err := d.dynamicClient.Resource(d.selectorFromCustomResource.groupVersionResource()).Namespace(apiv1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+	var (
+		sels []labels.Selector
+		keys []string
+	)
+	for _, item := range list.Items {
+		raw, found, err := unstructured.NestedMap(item.Object, "spec", "selector")
+		if err != nil || !found {
+			continue
+		}
+		var ls metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &ls); err != nil {
+			d.logger.Error("Invalid label selector in custom resource", "namespace", item.GetNamespace(), "name", item.GetName(), "err", err)
+			continue
+		}
+		sel, err := metav1.LabelSelectorAsSelector(&ls)
+		if err != nil {
+			d.logger.Error("Invalid label selector in custom resource", "namespace", item.GetNamespace(), "name", item.GetName(), "err", err)
+			continue
+		}
+		sels = append(sels, sel)
+		keys = append(keys, sel.String())
+	}
+	slices.Sort(keys)
+	return sels, strings.Join(keys, ","), nil
+}
+
+// maybeGateByCustomResourceSelectors wraps the role's primary ListerWatcher to
+// drop objects that no custom-resource-sourced selector matches. With no
+// custom resources present nothing is discovered, matching the operator model
+// where unselected workloads are not scraped. The caller must hold the lock.
+func (d *Discovery) maybeGateByCustomResourceSelectors(lw cache.ListerWatcher) cache.ListerWatcher {
+	if d.selectorFromCustomResource == nil {
+		return lw
+	}
+	return &selectorGateListerWatcher{inner: lw, selectors: d.crdSelectors}
+}
+
+type selectorGateListerWatcher struct {
+	inner     cache.ListerWatcher
+	selectors []labels.Selector
+}
+
+func (lw *selectorGateListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	obj, err := lw.inner.List(options)
+	if err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(obj)
+	if err != nil {
+		return nil, err
+	}
+	filtered := items[:0]
+	for _, o := range items {
+		if lw.matches(o) {
+			filtered = append(filtered, o)
+		}
+	}
+	if err := meta.SetList(obj, filtered); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (lw *selectorGateListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w, err := lw.inner.Watch(options)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+		return e, lw.matches(e.Object)
+	}), nil
+}
+
+func (lw *selectorGateListerWatcher) matches(o runtime.Object) bool {
+	acc, err := meta.Accessor(o)
+	if err != nil {
+		return true
+	}
+	set := labels.Set(acc.GetLabels())
+	for _, sel := range lw.selectors {
+		if sel.Matches(set) {
+			return true
 		}
 	}
-	return rs
+	return false
 }
 
-// Disable the informer's resync, which just periodically resends already processed updates and distort SD metrics.
-const resyncDisabled = 0
-
-// Run implements the discoverer interface.
-func (d *Discovery) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
+// runDiscoverers creates the informers and role discoverers for a single
+// discovery run and blocks until ctx is canceled.
+//
+// Informers are deliberately scoped to one Discovery instance rather than
+// shared process-wide across SD configurations: each configuration carries its
+// own client, selectors, namespace filtering and cache transforms, and the
+// informer lifecycle is tied to the run (discovery may be restarted, e.g. when
+// a selector ConfigMap changes). Sharing caches between overlapping
+// configurations would have to reconcile those per-config differences at the
+// discovery manager level.
+func (d *Discovery) runDiscoverers(ctx context.Context, ch chan<- []*targetgroup.Group) {
 	d.Lock()
+	d.discoverers = nil
+	d.informerSyncs = nil
+	d.roleStores = nil
+	d.cacheStores = nil
+	d.metrics.cacheSynced.WithLabelValues(d.role.String()).Set(0)
 
 	namespaces := d.getNamespaces()
 
 	switch d.role {
 	case RoleEndpointSlice:
 		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
 			var informer cache.SharedIndexInformer
 			e := d.client.DiscoveryV1().EndpointSlices(namespace)
 			elw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.endpointslice.field
-					options.LabelSelector = d.selectors.endpointslice.label
+					options.FieldSelector = selectors.endpointslice.field
+					options.LabelSelector = selectors.endpointslice.label
 					return e.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.endpointslice.field
-					options.LabelSelector = d.selectors.endpointslice.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.endpointslice.field
+					options.LabelSelector = selectors.endpointslice.label
 					return e.Watch(ctx, options)
 				},
 			}
-			informer = d.newIndexedEndpointSlicesInformer(elw, &disv1.EndpointSlice{})
+			informer = d.newIndexedEndpointSlicesInformer(d.maybeGateByCustomResourceSelectors(elw), &disv1.EndpointSlice{})
 
 			s := d.client.CoreV1().Services(namespace)
 			slw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.service.field
-					options.LabelSelector = d.selectors.service.label
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
 					return s.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.service.field
-					options.LabelSelector = d.selectors.service.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
 					return s.Watch(ctx, options)
 				},
 			}
 			p := d.client.CoreV1().Pods(namespace)
 			plw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.pod.field
-					options.LabelSelector = d.selectors.pod.label
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
 					return p.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.pod.field
-					options.LabelSelector = d.selectors.pod.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
 					return p.Watch(ctx, options)
 				},
 			}
 			var nodeInf cache.SharedInformer
 			if d.attachMetadata.Node {
 				nodeInf = d.newNodeInformer(context.Background())
-				go nodeInf.Run(ctx.Done())
+				d.runInformer(ctx, nodeInf)
 			}
 			var namespaceInf cache.SharedInformer
 			if d.attachMetadata.Namespace {
 				namespaceInf = d.newNamespaceInformer(context.Background())
-				go namespaceInf.Run(ctx.Done())
+				d.runInformer(ctx, namespaceInf)
+			}
+			var replicaSetInf cache.SharedInformer
+			if d.attachMetadata.OwnerWorkload {
+				replicaSetInf = d.newReplicaSetInformer(context.Background())
+				d.runInformer(ctx, replicaSetInf)
 			}
 			eps := NewEndpointSlice(
 				d.logger.With("role", "endpointslice"),
 				informer,
-				d.mustNewSharedInformer(slw, &apiv1.Service{}, resyncDisabled),
-				d.mustNewSharedInformer(plw, &apiv1.Pod{}, resyncDisabled),
+				d.mustNewSharedInformer(slw, &apiv1.Service{}),
+				d.mustNewSharedInformer(plw, &apiv1.Pod{}),
+				nodeInf,
+				namespaceInf,
+				replicaSetInf,
+				d.metrics.eventCount,
+				d.excludeNodeTaints,
+				d.attachMetadata.NamespaceLabelPrefixes,
+				d.appProtocols,
+				d.deduplicateEndpoints,
+				d.groupByService,
+				nil,
+			)
+			d.discoverers = append(d.discoverers, eps)
+			d.roleStores = append(d.roleStores, eps.endpointSliceStore)
+			d.runInformer(ctx, eps.endpointSliceInf)
+			d.runInformer(ctx, eps.serviceInf)
+			d.runInformer(ctx, eps.podInf)
+		}
+	case RoleServiceImport:
+		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
+			// Only the EndpointSlices derived from a ServiceImport are
+			// watched, which the MCS controller marks with the well-known
+			// service-name label.
+			label := mcsServiceNameLabel
+			if selectors.serviceimport.label != "" {
+				label = selectors.serviceimport.label + "," + mcsServiceNameLabel
+			}
+			e := d.client.DiscoveryV1().EndpointSlices(namespace)
+			elw := &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = selectors.serviceimport.field
+					options.LabelSelector = label
+					return e.List(ctx, options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.serviceimport.field
+					options.LabelSelector = label
+					return e.Watch(ctx, options)
+				},
+			}
+			informer := d.newIndexedEndpointSlicesInformer(elw, &disv1.EndpointSlice{})
+
+			s := d.client.CoreV1().Services(namespace)
+			slw := &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
+					return s.List(ctx, options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
+					return s.Watch(ctx, options)
+				},
+			}
+			p := d.client.CoreV1().Pods(namespace)
+			plw := &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
+					return p.List(ctx, options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
+					return p.Watch(ctx, options)
+				},
+			}
+			serviceImportInf := d.newServiceImportInformer(ctx, namespace)
+			d.runInformer(ctx, serviceImportInf)
+			var nodeInf cache.SharedInformer
+			if d.attachMetadata.Node {
+				nodeInf = d.newNodeInformer(context.Background())
+				d.runInformer(ctx, nodeInf)
+			}
+			var namespaceInf cache.SharedInformer
+			if d.attachMetadata.Namespace {
+				namespaceInf = d.newNamespaceInformer(context.Background())
+				d.runInformer(ctx, namespaceInf)
+			}
+			var replicaSetInf cache.SharedInformer
+			if d.attachMetadata.OwnerWorkload {
+				replicaSetInf = d.newReplicaSetInformer(context.Background())
+				d.runInformer(ctx, replicaSetInf)
+			}
+			eps := NewEndpointSlice(
+				d.logger.With("role", "serviceimport"),
+				informer,
+				d.mustNewSharedInformer(slw, &apiv1.Service{}),
+				d.mustNewSharedInformer(plw, &apiv1.Pod{}),
 				nodeInf,
 				namespaceInf,
+				replicaSetInf,
 				d.metrics.eventCount,
+				d.excludeNodeTaints,
+				d.attachMetadata.NamespaceLabelPrefixes,
+				d.appProtocols,
+				d.deduplicateEndpoints,
+				d.groupByService,
+				serviceImportInf,
 			)
 			d.discoverers = append(d.discoverers, eps)
-			go eps.endpointSliceInf.Run(ctx.Done())
-			go eps.serviceInf.Run(ctx.Done())
-			go eps.podInf.Run(ctx.Done())
+			d.roleStores = append(d.roleStores, eps.endpointSliceStore)
+			d.runInformer(ctx, eps.endpointSliceInf)
+			d.runInformer(ctx, eps.serviceInf)
+			d.runInformer(ctx, eps.podInf)
 		}
 	case RoleEndpoint:
 		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
 			e := d.client.CoreV1().Endpoints(namespace)
 			elw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.endpoints.field
-					options.LabelSelector = d.selectors.endpoints.label
+					options.FieldSelector = selectors.endpoints.field
+					options.LabelSelector = selectors.endpoints.label
 					return e.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.endpoints.field
-					options.LabelSelector = d.selectors.endpoints.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.endpoints.field
+					options.LabelSelector = selectors.endpoints.label
 					return e.Watch(ctx, options)
 				},
 			}
 			s := d.client.CoreV1().Services(namespace)
 			slw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.service.field
-					options.LabelSelector = d.selectors.service.label
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
 					return s.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.service.field
-					options.LabelSelector = d.selectors.service.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
 					return s.Watch(ctx, options)
 				},
 			}
 			p := d.client.CoreV1().Pods(namespace)
 			plw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.pod.field
-					options.LabelSelector = d.selectors.pod.label
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
 					return p.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.pod.field
-					options.LabelSelector = d.selectors.pod.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
 					return p.Watch(ctx, options)
 				},
 			}
 			var nodeInf cache.SharedInformer
 			if d.attachMetadata.Node {
 				nodeInf = d.newNodeInformer(ctx)
-				go nodeInf.Run(ctx.Done())
+				d.runInformer(ctx, nodeInf)
 			}
 			var namespaceInf cache.SharedInformer
 			if d.attachMetadata.Namespace {
 				namespaceInf = d.newNamespaceInformer(ctx)
-				go namespaceInf.Run(ctx.Done())
+				d.runInformer(ctx, namespaceInf)
+			}
+			var replicaSetInf cache.SharedInformer
+			if d.attachMetadata.OwnerWorkload {
+				replicaSetInf = d.newReplicaSetInformer(ctx)
+				d.runInformer(ctx, replicaSetInf)
 			}
 
 			eps := NewEndpoints(
 				d.logger.With("role", "endpoint"),
-				d.newIndexedEndpointsInformer(elw),
-				d.mustNewSharedInformer(slw, &apiv1.Service{}, resyncDisabled),
-				d.mustNewSharedInformer(plw, &apiv1.Pod{}, resyncDisabled),
+				d.newIndexedEndpointsInformer(d.maybeGateByCustomResourceSelectors(elw)),
+				d.mustNewSharedInformer(slw, &apiv1.Service{}),
+				d.mustNewSharedInformer(plw, &apiv1.Pod{}),
 				nodeInf,
 				namespaceInf,
+				replicaSetInf,
 				d.metrics.eventCount,
+				d.excludeNodeTaints,
+				d.attachMetadata.NamespaceLabelPrefixes,
+				d.endpointPortNameRe,
 			)
 			d.discoverers = append(d.discoverers, eps)
-			go eps.endpointsInf.Run(ctx.Done())
-			go eps.serviceInf.Run(ctx.Done())
-			go eps.podInf.Run(ctx.Done())
+			d.roleStores = append(d.roleStores, eps.endpointsStore)
+			d.runInformer(ctx, eps.endpointsInf)
+			d.runInformer(ctx, eps.serviceInf)
+			d.runInformer(ctx, eps.podInf)
+		}
+	case RoleAPIServer:
+		// The API server publishes itself as the "kubernetes" service in the
+		// "default" namespace; watch exactly that service and its endpoints.
+		// The pod informer shares the name selector and stays empty, as API
+		// server endpoints are not backed by pod objects.
+		const apiServerSelector = "metadata.name=kubernetes"
+		e := d.client.CoreV1().Endpoints(metav1.NamespaceDefault)
+		elw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = apiServerSelector
+				return e.List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.AllowWatchBookmarks = d.allowWatchBookmarks
+				options.FieldSelector = apiServerSelector
+				return e.Watch(ctx, options)
+			},
+		}
+		s := d.client.CoreV1().Services(metav1.NamespaceDefault)
+		slw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = apiServerSelector
+				return s.List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.AllowWatchBookmarks = d.allowWatchBookmarks
+				options.FieldSelector = apiServerSelector
+				return s.Watch(ctx, options)
+			},
 		}
+		p := d.client.CoreV1().Pods(metav1.NamespaceDefault)
+		plw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = apiServerSelector
+				return p.List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.AllowWatchBookmarks = d.allowWatchBookmarks
+				options.FieldSelector = apiServerSelector
+				return p.Watch(ctx, options)
+			},
+		}
+		eps := NewEndpoints(
+			d.logger.With("role", "apiserver"),
+			d.newIndexedEndpointsInformer(elw),
+			d.mustNewSharedInformer(slw, &apiv1.Service{}),
+			d.mustNewSharedInformer(plw, &apiv1.Pod{}),
+			nil,
+			nil,
+			nil,
+			d.metrics.eventCount,
+			d.excludeNodeTaints,
+			d.attachMetadata.NamespaceLabelPrefixes,
+			nil,
+		)
+		d.discoverers = append(d.discoverers, eps)
+		d.roleStores = append(d.roleStores, eps.endpointsStore)
+		d.runInformer(ctx, eps.endpointsInf)
+		d.runInformer(ctx, eps.serviceInf)
+		d.runInformer(ctx, eps.podInf)
 	case RolePod:
 		var nodeInformer cache.SharedInformer
 		if d.attachMetadata.Node {
 			nodeInformer = d.newNodeInformer(ctx)
-			go nodeInformer.Run(ctx.Done())
+			d.runInformer(ctx, nodeInformer)
 		}
 		var namespaceInformer cache.SharedInformer
 		if d.attachMetadata.Namespace {
 			namespaceInformer = d.newNamespaceInformer(ctx)
-			go namespaceInformer.Run(ctx.Done())
+			d.runInformer(ctx, namespaceInformer)
+		}
+		var replicaSetInformer cache.SharedInformer
+		if d.attachMetadata.OwnerWorkload {
+			replicaSetInformer = d.newReplicaSetInformer(ctx)
+			d.runInformer(ctx, replicaSetInformer)
 		}
 
 		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
 			p := d.client.CoreV1().Pods(namespace)
 			plw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.pod.field
-					options.LabelSelector = d.selectors.pod.label
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
 					return p.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.pod.field
-					options.LabelSelector = d.selectors.pod.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.pod.field
+					options.LabelSelector = selectors.pod.label
 					return p.Watch(ctx, options)
 				},
 			}
+			var leaseInformer cache.SharedInformer
+			var leaderAnnotation string
+			if le := d.leaderElection; le != nil {
+				switch le.Source {
+				case "lease":
+					leaseInformer = d.newLeaseInformer(ctx, namespace)
+					d.runInformer(ctx, leaseInformer)
+				case "annotation":
+					leaderAnnotation = le.Annotation
+					if leaderAnnotation == "" {
+						leaderAnnotation = defaultLeaderAnnotation
+					}
+				}
+			}
 			pod := NewPod(
 				d.logger.With("role", "pod"),
-				d.newIndexedPodsInformer(plw),
+				d.newIndexedPodsInformer(d.maybeGateByCustomResourceSelectors(plw)),
 				nodeInformer,
 				namespaceInformer,
+				replicaSetInformer,
 				d.metrics.eventCount,
+				d.includeVolumeMounts,
+				d.includeSpecHash,
+				d.defaultMetricsPort,
+				d.onlyNamedPorts,
+				d.podTargetsPerPod,
+				d.portAnnotation,
+				d.requirePodIP,
+				d.excludeTerminating,
+				d.excludeNodeTaints,
+				d.attachMetadata.NamespaceLabelPrefixes,
+				leaseInformer,
+				leaderAnnotation,
 			)
 			d.discoverers = append(d.discoverers, pod)
-			go pod.podInf.Run(ctx.Done())
+			d.roleStores = append(d.roleStores, pod.podInf.GetStore())
+			d.runInformer(ctx, pod.podInf)
 		}
 	case RoleService:
 		var namespaceInformer cache.SharedInformer
 		if d.attachMetadata.Namespace {
 			namespaceInformer = d.newNamespaceInformer(ctx)
-			go namespaceInformer.Run(ctx.Done())
+			d.runInformer(ctx, namespaceInformer)
 		}
 
 		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
 			s := d.client.CoreV1().Services(namespace)
 			slw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.service.field
-					options.LabelSelector = d.selectors.service.label
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
 					return s.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.service.field
-					options.LabelSelector = d.selectors.service.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.service.field
+					options.LabelSelector = selectors.service.label
 					return s.Watch(ctx, options)
 				},
 			}
@@ -578,28 +2513,40 @@ func (d *Discovery) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
 				d.newIndexedServicesInformer(slw),
 				namespaceInformer,
 				d.metrics.eventCount,
+				d.includeSpecHash,
+				d.loadBalancerIngressTargets,
+				d.attachMetadata.NamespaceLabelPrefixes,
+				d.appProtocols,
 			)
 			d.discoverers = append(d.discoverers, svc)
-			go svc.informer.Run(ctx.Done())
+			d.roleStores = append(d.roleStores, svc.store)
+			d.runInformer(ctx, svc.informer)
 		}
 	case RoleIngress:
 		var namespaceInformer cache.SharedInformer
 		if d.attachMetadata.Namespace {
 			namespaceInformer = d.newNamespaceInformer(ctx)
-			go namespaceInformer.Run(ctx.Done())
+			d.runInformer(ctx, namespaceInformer)
+		}
+		var ingressClassInformer cache.SharedInformer
+		if d.attachMetadata.IngressClass {
+			ingressClassInformer = d.newIngressClassInformer(ctx)
+			d.runInformer(ctx, ingressClassInformer)
 		}
 
 		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
 			i := d.client.NetworkingV1().Ingresses(namespace)
 			ilw := &cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = d.selectors.ingress.field
-					options.LabelSelector = d.selectors.ingress.label
+					options.FieldSelector = selectors.ingress.field
+					options.LabelSelector = selectors.ingress.label
 					return i.List(ctx, options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = d.selectors.ingress.field
-					options.LabelSelector = d.selectors.ingress.label
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.ingress.field
+					options.LabelSelector = selectors.ingress.label
 					return i.Watch(ctx, options)
 				},
 			}
@@ -607,20 +2554,186 @@ func (d *Discovery) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
 				d.logger.With("role", "ingress"),
 				d.newIndexedIngressesInformer(ilw),
 				namespaceInformer,
+				ingressClassInformer,
 				d.metrics.eventCount,
+				d.attachMetadata.NamespaceLabelPrefixes,
 			)
 			d.discoverers = append(d.discoverers, ingress)
-			go ingress.informer.Run(ctx.Done())
+			d.roleStores = append(d.roleStores, ingress.store)
+			d.runInformer(ctx, ingress.informer)
+		}
+	case RoleCronJob:
+		var namespaceInformer cache.SharedInformer
+		if d.attachMetadata.Namespace {
+			namespaceInformer = d.newNamespaceInformer(ctx)
+			d.runInformer(ctx, namespaceInformer)
+		}
+
+		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
+			c := d.client.BatchV1().CronJobs(namespace)
+			clw := &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = selectors.cronjob.field
+					options.LabelSelector = selectors.cronjob.label
+					return c.List(ctx, options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.cronjob.field
+					options.LabelSelector = selectors.cronjob.label
+					return c.Watch(ctx, options)
+				},
+			}
+			cronJob := NewCronJob(
+				d.logger.With("role", "cronjob"),
+				d.newIndexedCronJobsInformer(clw),
+				namespaceInformer,
+				d.metrics.eventCount,
+				d.attachMetadata.NamespaceLabelPrefixes,
+			)
+			d.discoverers = append(d.discoverers, cronJob)
+			d.roleStores = append(d.roleStores, cronJob.informer.GetStore())
+			d.runInformer(ctx, cronJob.informer)
+		}
+	case RolePVC:
+		var namespaceInformer cache.SharedInformer
+		if d.attachMetadata.Namespace {
+			namespaceInformer = d.newNamespaceInformer(ctx)
+			d.runInformer(ctx, namespaceInformer)
+		}
+
+		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
+			p := d.client.CoreV1().PersistentVolumeClaims(namespace)
+			plw := &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = selectors.pvc.field
+					options.LabelSelector = selectors.pvc.label
+					return p.List(ctx, options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.pvc.field
+					options.LabelSelector = selectors.pvc.label
+					return p.Watch(ctx, options)
+				},
+			}
+			pvc := NewPersistentVolumeClaim(
+				d.logger.With("role", "pvc"),
+				d.newIndexedPVCsInformer(plw),
+				namespaceInformer,
+				d.metrics.eventCount,
+				d.attachMetadata.NamespaceLabelPrefixes,
+			)
+			d.discoverers = append(d.discoverers, pvc)
+			d.roleStores = append(d.roleStores, pvc.informer.GetStore())
+			d.runInformer(ctx, pvc.informer)
 		}
 	case RoleNode:
 		nodeInformer := d.newNodeInformer(ctx)
-		node := NewNode(d.logger.With("role", "node"), nodeInformer, d.metrics.eventCount)
+		node := NewNode(d.logger.With("role", "node"), nodeInformer, d.metrics.eventCount, d.splitNodeEndpoints, d.nodeAddressPriority, d.excludeNodeTaints, d.attachMetadata.NodeCapacity)
 		d.discoverers = append(d.discoverers, node)
-		go node.informer.Run(ctx.Done())
+		d.roleStores = append(d.roleStores, node.informer.GetStore())
+		d.runInformer(ctx, node.informer)
+	case RoleCustomResource:
+		for _, namespace := range namespaces {
+			selectors := d.namespaceSelectors(namespace)
+			res := d.dynamicClient.Resource(d.customResource.groupVersionResource()).Namespace(namespace)
+			clw := &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = selectors.customresource.field
+					options.LabelSelector = selectors.customresource.label
+					return res.List(ctx, options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					options.AllowWatchBookmarks = d.allowWatchBookmarks
+					options.FieldSelector = selectors.customresource.field
+					options.LabelSelector = selectors.customresource.label
+					return res.Watch(ctx, options)
+				},
+			}
+			cr := NewCustomResource(
+				d.logger.With("role", "customresource"),
+				d.mustNewSharedInformer(clw, &unstructured.Unstructured{}),
+				d.customResource.AddressPath,
+				d.metrics.eventCount,
+			)
+			d.discoverers = append(d.discoverers, cr)
+			d.roleStores = append(d.roleStores, cr.informer.GetStore())
+			d.runInformer(ctx, cr.informer)
+		}
+	case RoleConfigMap:
+		ref := d.configMapTargets
+		cms := d.client.CoreV1().ConfigMaps(ref.Namespace)
+		clw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = "metadata.name=" + ref.Name
+				return cms.List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.AllowWatchBookmarks = d.allowWatchBookmarks
+				options.FieldSelector = "metadata.name=" + ref.Name
+				return cms.Watch(ctx, options)
+			},
+		}
+		cm := NewConfigMap(
+			d.logger.With("role", "configmap"),
+			d.mustNewSharedInformer(clw, &apiv1.ConfigMap{}),
+			ref.Key,
+			d.metrics.eventCount,
+		)
+		d.discoverers = append(d.discoverers, cm)
+		d.roleStores = append(d.roleStores, cm.informer.GetStore())
+		d.runInformer(ctx, cm.informer)
 	default:
 		d.logger.Error("unknown Kubernetes discovery kind", "role", d.role)
 	}
 
+	d.informerWG.Add(1)
+	go func() {
+		defer d.informerWG.Done()
+		ticker := time.NewTicker(cacheObjectsUpdateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.updateCacheObjects()
+			}
+		}
+	}()
+
+	syncs := slices.Clone(d.informerSyncs)
+	go func() {
+		stopCh := ctx.Done()
+		if d.syncTimeout > 0 {
+			timeoutCtx, cancel := context.WithTimeout(ctx, d.syncTimeout)
+			defer cancel()
+			stopCh = timeoutCtx.Done()
+		}
+		if cache.WaitForCacheSync(stopCh, syncs...) {
+			d.metrics.cacheSynced.WithLabelValues(d.role.String()).Set(1)
+			d.updateCacheObjects()
+			d.initialSyncOnce.Do(func() { close(d.initialSyncDone) })
+			if d.selectorFromConfigMap != nil || d.selectorFromCustomResource != nil {
+				// After a selector-change restart, clear the sources the
+				// previous run emitted for objects the new run no longer sees.
+				d.sweepStaleSources(ctx, ch)
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		// The caches did not sync within the timeout. Emit an empty target
+		// group under the role's name so downstream knows that discovery
+		// produced no results; the informers keep retrying in the background.
+		d.logger.Error("Kubernetes informer caches did not sync before the timeout", "role", d.role, "sync_timeout", d.syncTimeout.String())
+		send(ctx, ch, &targetgroup.Group{Source: d.role.String()})
+	}()
+
 	var wg sync.WaitGroup
 	for _, dd := range d.discoverers {
 		wg.Add(1)
@@ -634,6 +2747,9 @@ func (d *Discovery) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
 
 	wg.Wait()
 	<-ctx.Done()
+	// Only return once the informer goroutines have stopped as well, so that
+	// cancellation does not leak them.
+	d.informerWG.Wait()
 }
 
 func lv(s string) model.LabelValue {
@@ -674,12 +2790,75 @@ func (d *Discovery) newNodeInformer(ctx context.Context) cache.SharedInformer {
 			return d.client.CoreV1().Nodes().List(ctx, options)
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
 			options.FieldSelector = d.selectors.node.field
 			options.LabelSelector = d.selectors.node.label
 			return d.client.CoreV1().Nodes().Watch(ctx, options)
 		},
 	}
-	return d.mustNewSharedInformer(nlw, &apiv1.Node{}, resyncDisabled)
+	return d.mustNewSharedInformer(nlw, &apiv1.Node{})
+}
+
+func (d *Discovery) newLeaseInformer(ctx context.Context, namespace string) cache.SharedInformer {
+	l := d.client.CoordinationV1().Leases(namespace)
+	llw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return l.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
+			return l.Watch(ctx, options)
+		},
+	}
+	return d.mustNewSharedInformer(llw, &coordinationv1.Lease{})
+}
+
+func (d *Discovery) newIngressClassInformer(ctx context.Context) cache.SharedInformer {
+	// IngressClasses are cluster-scoped and few; no selectors apply.
+	ilw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return d.client.NetworkingV1().IngressClasses().List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
+			return d.client.NetworkingV1().IngressClasses().Watch(ctx, options)
+		},
+	}
+	return d.mustNewSharedInformer(ilw, &networkv1.IngressClass{})
+}
+
+// serviceImportGVR locates the ServiceImport resources of the MCS API. They
+// are watched via the dynamic client since the typed clientset does not know
+// the CRD-provided type.
+var serviceImportGVR = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+
+func (d *Discovery) newServiceImportInformer(ctx context.Context, namespace string) cache.SharedInformer {
+	res := d.dynamicClient.Resource(serviceImportGVR).Namespace(namespace)
+	silw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return res.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
+			return res.Watch(ctx, options)
+		},
+	}
+	return d.mustNewSharedInformer(silw, &unstructured.Unstructured{})
+}
+
+func (d *Discovery) newReplicaSetInformer(ctx context.Context) cache.SharedInformer {
+	// ReplicaSets are only used to resolve the Deployment owning a pod, so we
+	// don't filter on NamespaceDiscovery or selectors.
+	rlw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return d.client.AppsV1().ReplicaSets(apiv1.NamespaceAll).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
+			return d.client.AppsV1().ReplicaSets(apiv1.NamespaceAll).Watch(ctx, options)
+		},
+	}
+	return d.mustNewSharedInformer(rlw, &appsv1.ReplicaSet{})
 }
 
 func (d *Discovery) newNamespaceInformer(ctx context.Context) cache.SharedInformer {
@@ -689,13 +2868,14 @@ func (d *Discovery) newNamespaceInformer(ctx context.Context) cache.SharedInform
 			return d.client.CoreV1().Namespaces().List(ctx, options)
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.AllowWatchBookmarks = d.allowWatchBookmarks
 			return d.client.CoreV1().Namespaces().Watch(ctx, options)
 		},
 	}
-	return d.mustNewSharedInformer(nlw, &apiv1.Namespace{}, resyncDisabled)
+	return d.mustNewSharedInformer(nlw, &apiv1.Namespace{})
 }
 
-func (d *Discovery) newIndexedPodsInformer(plw *cache.ListWatch) cache.SharedIndexInformer {
+func (d *Discovery) newIndexedPodsInformer(plw cache.ListerWatcher) cache.SharedIndexInformer {
 	indexers := make(map[string]cache.IndexFunc)
 	if d.attachMetadata.Node {
 		indexers[nodeIndex] = func(obj interface{}) ([]string, error) {
@@ -711,10 +2891,10 @@ func (d *Discovery) newIndexedPodsInformer(plw *cache.ListWatch) cache.SharedInd
 		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
 	}
 
-	return d.mustNewSharedIndexInformer(plw, &apiv1.Pod{}, resyncDisabled, indexers)
+	return d.mustNewSharedIndexInformer(plw, &apiv1.Pod{}, indexers)
 }
 
-func (d *Discovery) newIndexedEndpointsInformer(plw *cache.ListWatch) cache.SharedIndexInformer {
+func (d *Discovery) newIndexedEndpointsInformer(plw cache.ListerWatcher) cache.SharedIndexInformer {
 	indexers := make(map[string]cache.IndexFunc)
 	indexers[podIndex] = func(obj interface{}) ([]string, error) {
 		e, ok := obj.(*apiv1.Endpoints)
@@ -761,10 +2941,10 @@ func (d *Discovery) newIndexedEndpointsInformer(plw *cache.ListWatch) cache.Shar
 		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
 	}
 
-	return d.mustNewSharedIndexInformer(plw, &apiv1.Endpoints{}, resyncDisabled, indexers)
+	return d.mustNewSharedIndexInformer(plw, &apiv1.Endpoints{}, indexers)
 }
 
-func (d *Discovery) newIndexedEndpointSlicesInformer(plw *cache.ListWatch, object runtime.Object) cache.SharedIndexInformer {
+func (d *Discovery) newIndexedEndpointSlicesInformer(plw cache.ListerWatcher, object runtime.Object) cache.SharedIndexInformer {
 	indexers := make(map[string]cache.IndexFunc)
 	indexers[serviceIndex] = func(obj interface{}) ([]string, error) {
 		e, ok := obj.(*disv1.EndpointSlice)
@@ -809,7 +2989,7 @@ func (d *Discovery) newIndexedEndpointSlicesInformer(plw *cache.ListWatch, objec
 		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
 	}
 
-	return d.mustNewSharedIndexInformer(plw, object, resyncDisabled, indexers)
+	return d.mustNewSharedIndexInformer(plw, object, indexers)
 }
 
 func (d *Discovery) newIndexedServicesInformer(slw *cache.ListWatch) cache.SharedIndexInformer {
@@ -819,7 +2999,27 @@ func (d *Discovery) newIndexedServicesInformer(slw *cache.ListWatch) cache.Share
 		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
 	}
 
-	return d.mustNewSharedIndexInformer(slw, &apiv1.Service{}, resyncDisabled, indexers)
+	return d.mustNewSharedIndexInformer(slw, &apiv1.Service{}, indexers)
+}
+
+func (d *Discovery) newIndexedCronJobsInformer(clw *cache.ListWatch) cache.SharedIndexInformer {
+	indexers := make(map[string]cache.IndexFunc)
+
+	if d.attachMetadata.Namespace {
+		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
+	}
+
+	return d.mustNewSharedIndexInformer(clw, &batchv1.CronJob{}, indexers)
+}
+
+func (d *Discovery) newIndexedPVCsInformer(plw *cache.ListWatch) cache.SharedIndexInformer {
+	indexers := make(map[string]cache.IndexFunc)
+
+	if d.attachMetadata.Namespace {
+		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
+	}
+
+	return d.mustNewSharedIndexInformer(plw, &apiv1.PersistentVolumeClaim{}, indexers)
 }
 
 func (d *Discovery) newIndexedIngressesInformer(ilw *cache.ListWatch) cache.SharedIndexInformer {
@@ -829,34 +3029,274 @@ func (d *Discovery) newIndexedIngressesInformer(ilw *cache.ListWatch) cache.Shar
 		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
 	}
 
-	return d.mustNewSharedIndexInformer(ilw, &networkv1.Ingress{}, resyncDisabled, indexers)
+	return d.mustNewSharedIndexInformer(ilw, &networkv1.Ingress{}, indexers)
 }
 
 func (d *Discovery) informerWatchErrorHandler(r *cache.Reflector, err error) {
 	d.metrics.failuresCount.Inc()
+	reason := MetricReasonOther
+	switch {
+	case apierrors.IsForbidden(err):
+		reason = MetricReasonForbidden
+	case apierrors.IsUnauthorized(err):
+		reason = MetricReasonUnauthorized
+	}
+	d.metrics.watchErrorsCount.WithLabelValues(d.role.String(), reason).Inc()
 	cache.DefaultWatchErrorHandler(r, err)
+
+	d.Lock()
+	defer d.Unlock()
+	d.failures++
+	d.lastWatchError = err
+	if d.maxFailures > 0 && d.failures >= d.maxFailures && d.cancelDiscovery != nil {
+		d.logger.Error("Kubernetes discovery exhausted its failure budget, stopping", "role", d.role, "max_failures", d.maxFailures, "err", err)
+		d.cancelDiscovery()
+		d.cancelDiscovery = nil
+	}
+}
+
+// countWatchRestarts wraps a ListerWatcher to count watch re-establishments
+// for the role. client-go does not surface reflector restarts directly, so
+// every Watch invocation after the first is counted: the reflector opens one
+// watch per ListAndWatch round, but expired watches resumed without a fresh
+// LIST are counted the same way. The count is an approximation either way and
+// is meant to detect flapping, not to audit individual reconnects.
+func (d *Discovery) countWatchRestarts(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &restartCountingListerWatcher{
+		inner:   lw,
+		counter: d.metrics.watchReestablishedCount.WithLabelValues(d.role.String()),
+	}
+}
+
+type restartCountingListerWatcher struct {
+	inner   cache.ListerWatcher
+	counter prometheus.Counter
+
+	mtx     sync.Mutex
+	watched bool
+}
+
+func (lw *restartCountingListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	return lw.inner.List(options)
+}
+
+func (lw *restartCountingListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	lw.mtx.Lock()
+	if lw.watched {
+		lw.counter.Inc()
+	} else {
+		lw.watched = true
+	}
+	lw.mtx.Unlock()
+	return lw.inner.Watch(options)
+}
+
+// maybeLimitListPageSize wraps a ListerWatcher to cap the number of objects
+// returned per LIST request, so the reflector paginates the initial listing
+// instead of issuing one unbounded LIST. Watches are unaffected.
+func (d *Discovery) maybeLimitListPageSize(lw cache.ListerWatcher) cache.ListerWatcher {
+	if d.listPageSize <= 0 {
+		return lw
+	}
+	return &limitListerWatcher{inner: lw, pageSize: d.listPageSize}
+}
+
+// maybeListFromWatchCache wraps a ListerWatcher to list with
+// resourceVersion="0", so the API server serves the initial LIST from its
+// watch cache instead of a quorum read against etcd. The returned data may
+// be slightly stale; the watch started right after catches up, so this only
+// affects how fresh the very first target snapshot is. Watches are
+// unaffected.
+func (d *Discovery) maybeListFromWatchCache(lw cache.ListerWatcher) cache.ListerWatcher {
+	if !d.listFromWatchCache {
+		return lw
+	}
+	return &watchCacheListerWatcher{inner: lw}
+}
+
+type watchCacheListerWatcher struct {
+	inner cache.ListerWatcher
+}
+
+func (lw *watchCacheListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	options.ResourceVersion = "0"
+	return lw.inner.List(options)
+}
+
+func (lw *watchCacheListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return lw.inner.Watch(options)
+}
+
+type limitListerWatcher struct {
+	inner    cache.ListerWatcher
+	pageSize int64
+}
+
+func (lw *limitListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	if options.Limit == 0 {
+		options.Limit = lw.pageSize
+	}
+	return lw.inner.List(options)
+}
+
+func (lw *limitListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return lw.inner.Watch(options)
+}
+
+// maybeExcludeNamespaces wraps a ListerWatcher to drop objects from excluded
+// namespaces. Cluster-scoped objects such as nodes and namespaces carry no
+// namespace and always pass through.
+func (d *Discovery) maybeExcludeNamespaces(lw cache.ListerWatcher) cache.ListerWatcher {
+	if len(d.namespaceDiscovery.ExcludeNames) == 0 {
+		return lw
+	}
+	return &excludeNamespacesListerWatcher{inner: lw, excluded: d.namespaceDiscovery.ExcludeNames}
+}
+
+type excludeNamespacesListerWatcher struct {
+	inner    cache.ListerWatcher
+	excluded []string
+}
+
+func (lw *excludeNamespacesListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	obj, err := lw.inner.List(options)
+	if err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(obj)
+	if err != nil {
+		return nil, err
+	}
+	filtered := items[:0]
+	for _, o := range items {
+		if !lw.excludes(o) {
+			filtered = append(filtered, o)
+		}
+	}
+	if err := meta.SetList(obj, filtered); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (lw *excludeNamespacesListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w, err := lw.inner.Watch(options)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+		return e, !lw.excludes(e.Object)
+	}), nil
+}
+
+func (lw *excludeNamespacesListerWatcher) excludes(o runtime.Object) bool {
+	acc, err := meta.Accessor(o)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(lw.excluded, acc.GetNamespace())
+}
+
+// lastAppliedConfigAnnotation can hold a full serialized copy of the object
+// and is by far the largest annotation in typical clusters.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// trimObjectForCache strips fields that service discovery never reads before
+// objects enter the informer cache, trading relabeling on those fields for a
+// smaller memory footprint in large clusters.
+func trimObjectForCache(obj interface{}) (interface{}, error) {
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		// E.g. cache.DeletedFinalStateUnknown tombstones.
+		return obj, nil
+	}
+	acc.SetManagedFields(nil)
+	if annotations := acc.GetAnnotations(); annotations != nil {
+		delete(annotations, lastAppliedConfigAnnotation)
+		acc.SetAnnotations(annotations)
+	}
+	if pod, ok := obj.(*apiv1.Pod); ok {
+		for i := range pod.Spec.Containers {
+			pod.Spec.Containers[i].Env = nil
+		}
+		for i := range pod.Spec.InitContainers {
+			pod.Spec.InitContainers[i].Env = nil
+		}
+	}
+	return obj, nil
 }
 
-func (d *Discovery) mustNewSharedInformer(lw cache.ListerWatcher, exampleObject runtime.Object, defaultEventHandlerResyncPeriod time.Duration) cache.SharedInformer {
-	informer := cache.NewSharedInformer(lw, exampleObject, defaultEventHandlerResyncPeriod)
+// mustNewUnregisteredSharedInformer builds an informer without registering it
+// in informerSyncs and cacheStores. It is used for the auxiliary dynamic
+// selector watches, which outlive individual discovery runs: the registration
+// slices belong to one run and are reset by runDiscoverers, and appending to
+// them outside its lock would race with Status and updateCacheObjects.
+func (d *Discovery) mustNewUnregisteredSharedInformer(lw cache.ListerWatcher, exampleObject runtime.Object) cache.SharedInformer {
+	informer := cache.NewSharedInformer(d.countWatchRestarts(d.maybeExcludeNamespaces(d.maybeLimitListPageSize(d.maybeListFromWatchCache(lw)))), exampleObject, d.resyncPeriod)
 	// Invoking SetWatchErrorHandler should fail only if the informer has been started beforehand.
 	// Such a scenario would suggest an incorrect use of the API, thus the panic.
 	if err := informer.SetWatchErrorHandler(d.informerWatchErrorHandler); err != nil {
 		panic(err)
 	}
+	if d.trimObjectCache {
+		if err := informer.SetTransform(trimObjectForCache); err != nil {
+			panic(err)
+		}
+	}
+	return informer
+}
+
+func (d *Discovery) mustNewSharedInformer(lw cache.ListerWatcher, exampleObject runtime.Object) cache.SharedInformer {
+	informer := d.mustNewUnregisteredSharedInformer(lw, exampleObject)
+	d.informerSyncs = append(d.informerSyncs, informer.HasSynced)
+	d.recordCacheStore(exampleObject, informer.GetStore())
 	return informer
 }
 
-func (d *Discovery) mustNewSharedIndexInformer(lw cache.ListerWatcher, exampleObject runtime.Object, defaultEventHandlerResyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
-	informer := cache.NewSharedIndexInformer(lw, exampleObject, defaultEventHandlerResyncPeriod, indexers)
+func (d *Discovery) mustNewSharedIndexInformer(lw cache.ListerWatcher, exampleObject runtime.Object, indexers cache.Indexers) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(d.countWatchRestarts(d.maybeExcludeNamespaces(d.maybeLimitListPageSize(d.maybeListFromWatchCache(lw)))), exampleObject, d.resyncPeriod, indexers)
 	// Invoking SetWatchErrorHandler should fail only if the informer has been started beforehand.
 	// Such a scenario would suggest an incorrect use of the API, thus the panic.
 	if err := informer.SetWatchErrorHandler(d.informerWatchErrorHandler); err != nil {
 		panic(err)
 	}
+	if d.trimObjectCache {
+		if err := informer.SetTransform(trimObjectForCache); err != nil {
+			panic(err)
+		}
+	}
+	d.informerSyncs = append(d.informerSyncs, informer.HasSynced)
+	d.recordCacheStore(exampleObject, informer.GetStore())
 	return informer
 }
 
+// cacheStoreRef pairs an informer store with the kind of object it holds.
+type cacheStoreRef struct {
+	kind  string
+	store cache.Store
+}
+
+// recordCacheStore remembers an informer store for the cache objects gauge,
+// deriving the kind label from the informer's example object.
+func (d *Discovery) recordCacheStore(exampleObject runtime.Object, store cache.Store) {
+	kind := reflect.TypeOf(exampleObject).Elem().Name()
+	d.cacheStores = append(d.cacheStores, cacheStoreRef{kind: kind, store: store})
+}
+
+// updateCacheObjects reports how many objects the informer caches currently
+// hold, summed per kind across the per-namespace informers.
+func (d *Discovery) updateCacheObjects() {
+	d.RLock()
+	counts := make(map[string]int, len(d.cacheStores))
+	for _, ref := range d.cacheStores {
+		counts[ref.kind] += len(ref.store.ListKeys())
+	}
+	d.RUnlock()
+	for kind, count := range counts {
+		d.metrics.cacheObjects.WithLabelValues(d.role.String(), kind).Set(float64(count))
+	}
+}
+
 func addObjectAnnotationsAndLabels(labelSet model.LabelSet, objectMeta metav1.ObjectMeta, resource string) {
 	for k, v := range objectMeta.Labels {
 		ln := strutil.SanitizeLabelName(k)
@@ -875,8 +3315,21 @@ func addObjectMetaLabels(labelSet model.LabelSet, objectMeta metav1.ObjectMeta,
 	addObjectAnnotationsAndLabels(labelSet, objectMeta, string(role))
 }
 
-func addNamespaceMetaLabels(labelSet model.LabelSet, objectMeta metav1.ObjectMeta) {
+func addNamespaceMetaLabels(labelSet model.LabelSet, objectMeta metav1.ObjectMeta, labelPrefixes []string) {
 	// Omitting the namespace name because should be already injected elsewhere.
+	if len(labelPrefixes) > 0 {
+		filtered := make(map[string]string)
+		for k, v := range objectMeta.Labels {
+			for _, prefix := range labelPrefixes {
+				if strings.HasPrefix(k, prefix) {
+					filtered[k] = v
+					break
+				}
+			}
+		}
+		// objectMeta is a copy, the stored object is left untouched.
+		objectMeta.Labels = filtered
+	}
 	addObjectAnnotationsAndLabels(labelSet, objectMeta, "namespace")
 }
 
@@ -884,6 +3337,29 @@ func namespacedName(namespace, name string) string {
 	return namespace + "/" + name
 }
 
+// specHash returns a stable hash over the given normalized parts of an
+// object's spec. Objects whose scrape-relevant fields are unchanged keep the
+// same hash, so external systems can skip reprocessing no-op updates.
+func specHash(parts ...string) model.LabelValue {
+	h := fnv.New64a()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0xff})
+	}
+	return lv(strconv.FormatUint(h.Sum64(), 16))
+}
+
+// sortedObjectLabels normalizes an object's labels into a sorted list of
+// key=value strings for hashing.
+func sortedObjectLabels(objectLabels map[string]string) []string {
+	ls := make([]string, 0, len(objectLabels))
+	for k, v := range objectLabels {
+		ls = append(ls, k+"="+v)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
 // nodeName knows how to handle the cache.DeletedFinalStateUnknown tombstone.
 // It assumes the MetaNamespaceKeyFunc keyFunc is used, which uses the node name as the tombstone key.
 func nodeName(o interface{}) (string, error) {
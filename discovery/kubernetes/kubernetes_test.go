@@ -15,26 +15,45 @@ package kubernetes
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/promslog"
 	"github.com/stretchr/testify/require"
 	apiv1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apimachinery/pkg/watch"
 	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
@@ -58,7 +77,7 @@ func makeDiscoveryWithVersion(role Role, nsDiscovery NamespaceDiscovery, k8sVer
 
 	reg := prometheus.NewRegistry()
 	refreshMetrics := discovery.NewRefreshMetrics(reg)
-	metrics := newDiscovererMetrics(reg, refreshMetrics)
+	metrics := newDiscovererMetrics("", reg, refreshMetrics)
 	err := metrics.Register()
 	if err != nil {
 		panic(err)
@@ -76,6 +95,8 @@ func makeDiscoveryWithVersion(role Role, nsDiscovery NamespaceDiscovery, k8sVer
 		role:               role,
 		namespaceDiscovery: &nsDiscovery,
 		ownNamespace:       "own-ns",
+		initialSyncDone:    make(chan struct{}),
+		activeSources:      map[string]int{},
 		metrics:            kubeMetrics,
 	}
 
@@ -232,6 +253,8 @@ var (
 	_ hasSynced = &Ingress{}
 	_ hasSynced = &Pod{}
 	_ hasSynced = &Service{}
+	_ hasSynced = &CronJob{}
+	_ hasSynced = &CustomResource{}
 )
 
 func (d *Discovery) hasSynced() bool {
@@ -271,6 +294,595 @@ func (s *Service) hasSynced() bool {
 	return s.informer.HasSynced()
 }
 
+func (c *CronJob) hasSynced() bool {
+	return c.informer.HasSynced()
+}
+
+func (c *CustomResource) hasSynced() bool {
+	return c.informer.HasSynced()
+}
+
+// TestRunReturnsAfterCancel ensures that cancellation unblocks discoverers
+// stuck sending on an unread channel, and that Run only returns once all
+// informer goroutines have stopped. Leaks are caught by TestMain's goleak.
+func TestRunReturnsAfterCancel(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	obj := makePods("default")
+	c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Unbuffered and never read, so send blocks until cancellation.
+	ch := make(chan []*targetgroup.Group)
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx, ch)
+		close(done)
+	}()
+
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), n.hasSynced))
+	// Give the discoverer time to block on the channel.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+func TestTargetsCountMetric(t *testing.T) {
+	t.Parallel()
+	n, _ := makeDiscovery(RolePod, NamespaceDiscovery{}, makePods("ns1"), makePods("ns2"))
+	gauge := n.metrics.targetsCount.WithLabelValues(RolePod.String())
+
+	expected := expectedPodTargetGroups("ns1")
+	for k, v := range expectedPodTargetGroups("ns2") {
+		expected[k] = v
+	}
+	k8sDiscoveryTest{
+		discovery:        n,
+		expectedMaxItems: 2,
+		expectedRes:      expected,
+	}.Run(t)
+	require.Equal(t, float64(2), prom_testutil.ToFloat64(gauge))
+}
+
+func TestCacheObjectsMetric(t *testing.T) {
+	t.Parallel()
+	n, _ := makeDiscovery(RolePod, NamespaceDiscovery{}, makePods("ns1"), makePods("ns2"))
+	gauge := n.metrics.cacheObjects.WithLabelValues(RolePod.String(), "Pod")
+
+	expected := expectedPodTargetGroups("ns1")
+	for k, v := range expectedPodTargetGroups("ns2") {
+		expected[k] = v
+	}
+	k8sDiscoveryTest{
+		discovery:        n,
+		expectedMaxItems: 2,
+		expectedRes:      expected,
+	}.Run(t)
+	// The periodic refresh may not have fired yet within the test window.
+	n.updateCacheObjects()
+	require.Equal(t, float64(2), prom_testutil.ToFloat64(gauge))
+}
+
+func TestTargetsCountMetricDelete(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{}, makePods("default"))
+	gauge := n.metrics.targetsCount.WithLabelValues(RolePod.String())
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			c.CoreV1().Pods("default").Delete(context.Background(), "testpod", metav1.DeleteOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"pod/default/testpod": {
+				Source: "pod/default/testpod",
+			},
+		},
+	}.Run(t)
+	require.Equal(t, float64(0), prom_testutil.ToFloat64(gauge))
+}
+
+func TestSyncTimeoutEmitsEmptyGroup(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.syncTimeout = 100 * time.Millisecond
+	// Listing pods fails permanently, e.g. due to missing RBAC permissions,
+	// so the cache never syncs.
+	c.(*fake.Clientset).PrependReactor("list", "pods", func(_ kubetesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan []*targetgroup.Group, 1)
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx, ch)
+		close(done)
+	}()
+
+	select {
+	case tgs := <-ch:
+		require.Len(t, tgs, 1)
+		require.Equal(t, RolePod.String(), tgs[0].Source)
+		require.Empty(t, tgs[0].Targets)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected an empty target group after the sync timeout")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+func TestInitialSyncGracePeriodReleasedOnSync(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	// The grace period is far longer than the test harness waits; results
+	// only arrive in time because the cache sync releases the gate early.
+	n.initialSyncGracePeriod = 30 * time.Second
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expectedPodTargetGroups("default"),
+	}.Run(t)
+}
+
+func TestDelayInitialSendFlushesOnGracePeriod(t *testing.T) {
+	t.Parallel()
+	d := &Discovery{
+		initialSyncGracePeriod: 100 * time.Millisecond,
+		initialSyncDone:        make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan []*targetgroup.Group, 1)
+	in := d.delayInitialSend(ctx, out)
+
+	// Only the latest group per source survives the buffering.
+	in <- []*targetgroup.Group{{Source: "a", Targets: []model.LabelSet{{"x": "1"}}}}
+	in <- []*targetgroup.Group{{Source: "b"}}
+	in <- []*targetgroup.Group{{Source: "a", Targets: []model.LabelSet{{"x": "1"}, {"x": "2"}}}}
+
+	select {
+	case tgs := <-out:
+		require.Len(t, tgs, 2)
+		require.Equal(t, "a", tgs[0].Source)
+		require.Len(t, tgs[0].Targets, 2)
+		require.Equal(t, "b", tgs[1].Source)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected buffered groups to be flushed after the grace period")
+	}
+
+	// After the flush, groups are forwarded transparently.
+	in <- []*targetgroup.Group{{Source: "c"}}
+	select {
+	case tgs := <-out:
+		require.Len(t, tgs, 1)
+		require.Equal(t, "c", tgs[0].Source)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected groups to be forwarded after the flush")
+	}
+}
+
+func TestDebounceUpdatesCoalesces(t *testing.T) {
+	t.Parallel()
+	d := &Discovery{updateDebounce: 100 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan []*targetgroup.Group, 1)
+	in := d.debounceUpdates(ctx, out)
+
+	// Updates within the window coalesce to the latest group per source;
+	// the deletion of "b" must survive.
+	in <- []*targetgroup.Group{{Source: "a", Targets: []model.LabelSet{{"x": "1"}}}}
+	in <- []*targetgroup.Group{{Source: "b", Targets: []model.LabelSet{{"x": "1"}}}}
+	in <- []*targetgroup.Group{{Source: "b"}}
+	in <- []*targetgroup.Group{{Source: "a", Targets: []model.LabelSet{{"x": "1"}, {"x": "2"}}}}
+
+	select {
+	case tgs := <-out:
+		require.Len(t, tgs, 2)
+		require.Equal(t, "a", tgs[0].Source)
+		require.Len(t, tgs[0].Targets, 2)
+		require.Equal(t, "b", tgs[1].Source)
+		require.Empty(t, tgs[1].Targets)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected coalesced batch after the debounce window")
+	}
+
+	// A later update opens a fresh window and is not mixed with the
+	// previous batch.
+	in <- []*targetgroup.Group{{Source: "c"}}
+	select {
+	case tgs := <-out:
+		require.Len(t, tgs, 1)
+		require.Equal(t, "c", tgs[0].Source)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the next window to flush independently")
+	}
+}
+
+func TestClusterNameLabel(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.clusterName = "test-cluster"
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_cluster_name"] = "test-cluster"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestSanitizeLabelValues(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.sanitizeLabelValues = true
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_annotation_binary"] = "a�b"
+		tg.Labels["__meta_kubernetes_pod_annotationpresent_binary"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Annotations = map[string]string{"binary": "a\xffb"}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestMetricsNamespaceOverride(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	conf := &SDConfig{MetricsNamespace: "embedded_sd_kubernetes"}
+	metrics := conf.NewDiscovererMetrics(reg, discovery.NewRefreshMetrics(reg))
+	require.NoError(t, metrics.Register())
+	defer metrics.Unregister()
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	names := make([]string, 0, len(mfs))
+	for _, mf := range mfs {
+		names = append(names, mf.GetName())
+	}
+	require.Contains(t, names, "embedded_sd_kubernetes_events_total")
+	require.NotContains(t, names, "prometheus_sd_kubernetes_events_total")
+}
+
+func TestDiscoveryLister(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	// Before Run has started any informers there is nothing to list.
+	_, err := n.Lister(RolePod)
+	require.Error(t, err)
+	// The requested role must match the configured one.
+	_, err = n.Lister(RoleService)
+	require.Error(t, err)
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		afterStart: func() {
+			lister, err := n.Lister(RolePod)
+			require.NoError(t, err)
+			require.Eventually(t, func() bool {
+				objs, err := lister.List(labels.Everything())
+				return err == nil && len(objs) == 1
+			}, 10*time.Second, 10*time.Millisecond)
+			_, err = lister.ByNamespace("default").Get("testpod")
+			require.NoError(t, err)
+			_, err = lister.ByNamespace("default").Get("missing")
+			require.Error(t, err)
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expectedPodTargetGroups("default"),
+	}.Run(t)
+}
+
+// TestTargetGroupSources pins the exact Source formats: they identify target
+// groups across reloads and restarts, so any change would make downstream
+// consumers treat all targets as new.
+func TestTargetGroupSources(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "endpoints/ns1/name1", endpointsSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "endpointslice/ns1/name1", endpointSliceSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "svc/ns1/name1", serviceSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "pod/ns1/name1", podSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "ingress/ns1/name1", ingressSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "cronjob/ns1/name1", cronJobSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "pvc/ns1/name1", pvcSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "customresource/ns1/name1", customResourceSourceFromNamespaceAndName("ns1", "name1"))
+	require.Equal(t, "node/name1", nodeSourceFromName("name1"))
+}
+
+func TestDiscoveryStatus(t *testing.T) {
+	t.Parallel()
+	n, _ := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	// Before Run has started any informers there is nothing synced.
+	status := n.Status()
+	require.Equal(t, RolePod, status.Role)
+	require.False(t, status.Synced)
+	require.Zero(t, status.TargetCount)
+	require.NoError(t, status.LastWatchError)
+
+	// The target count follows the groups passing through trackTargets.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := n.trackTargets(ctx, make(chan []*targetgroup.Group, 2))
+	in <- []*targetgroup.Group{{Source: "a", Targets: []model.LabelSet{{"x": "1"}, {"x": "2"}}}}
+	require.Eventually(t, func() bool {
+		return n.Status().TargetCount == 2
+	}, 10*time.Second, 10*time.Millisecond)
+	in <- []*targetgroup.Group{{Source: "a"}}
+	require.Eventually(t, func() bool {
+		return n.Status().TargetCount == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// Watch errors surface in the snapshot.
+	n.Lock()
+	n.failures = 3
+	n.lastWatchError = errors.New("watch failed")
+	n.Unlock()
+	status = n.Status()
+	require.Equal(t, 3, status.Failures)
+	require.EqualError(t, status.LastWatchError, "watch failed")
+}
+
+func TestLimitListerWatcherAppliesPageSize(t *testing.T) {
+	t.Parallel()
+	var gotLimit int64
+	lw := &limitListerWatcher{
+		inner: &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				gotLimit = options.Limit
+				return &apiv1.PodList{}, nil
+			},
+		},
+		pageSize: 500,
+	}
+	_, err := lw.List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int64(500), gotLimit)
+	// An explicit limit, e.g. from the reflector's pager, takes precedence.
+	_, err = lw.List(metav1.ListOptions{Limit: 100})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), gotLimit)
+}
+
+func TestWatchCacheListerWatcherSetsResourceVersion(t *testing.T) {
+	t.Parallel()
+	var gotRV string
+	lw := &watchCacheListerWatcher{
+		inner: &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				gotRV = options.ResourceVersion
+				return &apiv1.PodList{}, nil
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				gotRV = options.ResourceVersion
+				return watch.NewFake(), nil
+			},
+		},
+	}
+	_, err := lw.List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "0", gotRV)
+	// Watches pass through untouched so the reflector resumes from the
+	// resource version the LIST returned.
+	w, err := lw.Watch(metav1.ListOptions{ResourceVersion: "42"})
+	require.NoError(t, err)
+	w.Stop()
+	require.Equal(t, "42", gotRV)
+}
+
+func TestBuildRestConfigTLSServerName(t *testing.T) {
+	t.Parallel()
+	// A certificate valid for a name that differs from the host the test
+	// dials, as when reaching the API server through a proxy whose address is
+	// not in the certificate's SANs.
+	const serverName = "kubernetes.example.com"
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: serverName},
+		DNSNames:              []string{serverName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+	apiURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	get := func(overrideName string) error {
+		conf := &SDConfig{
+			APIServer: promconfig.URL{URL: apiURL},
+			HTTPClientConfig: promconfig.HTTPClientConfig{
+				TLSConfig: promconfig.TLSConfig{CAFile: caFile, ServerName: overrideName},
+			},
+		}
+		kcfg, _, err := buildRestConfig(promslog.NewNopLogger(), conf)
+		require.NoError(t, err)
+		rt, err := rest.TransportFor(kcfg)
+		require.NoError(t, err)
+		client := &http.Client{Transport: rt}
+		defer client.CloseIdleConnections()
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	// Without the override the certificate does not match the dialed host.
+	require.Error(t, get(""))
+	// tls_config.server_name must be honored by the round tripper handed to
+	// client-go.
+	require.NoError(t, get(serverName))
+}
+
+func TestBuildRestConfigImpersonation(t *testing.T) {
+	t.Parallel()
+	var (
+		gotUser   string
+		gotGroups []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = r.Header.Get("Impersonate-User")
+		gotGroups = r.Header.Values("Impersonate-Group")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	apiURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	conf := &SDConfig{
+		APIServer:         promconfig.URL{URL: apiURL},
+		ImpersonateUser:   "system:serviceaccount:monitoring:restricted",
+		ImpersonateGroups: []string{"group-a", "group-b"},
+	}
+	kcfg, _, err := buildRestConfig(promslog.NewNopLogger(), conf)
+	require.NoError(t, err)
+
+	rt, err := rest.TransportFor(kcfg)
+	require.NoError(t, err)
+	client := &http.Client{Transport: rt}
+	defer client.CloseIdleConnections()
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, "system:serviceaccount:monitoring:restricted", gotUser)
+	require.Equal(t, []string{"group-a", "group-b"}, gotGroups)
+}
+
+func TestBuildRestConfigBearerTokenFileRotation(t *testing.T) {
+	t.Parallel()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-1"), 0o600))
+
+	// The server only accepts the token currently in the file.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := os.ReadFile(tokenFile)
+		require.NoError(t, err)
+		if r.Header.Get("Authorization") != "Bearer "+string(token) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	apiURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	conf := &SDConfig{
+		APIServer: promconfig.URL{URL: apiURL},
+		HTTPClientConfig: promconfig.HTTPClientConfig{
+			Authorization: &promconfig.Authorization{CredentialsFile: tokenFile},
+		},
+	}
+	kcfg, _, err := buildRestConfig(promslog.NewNopLogger(), conf)
+	require.NoError(t, err)
+	require.Equal(t, tokenFile, kcfg.BearerTokenFile)
+
+	rt, err := rest.TransportFor(kcfg)
+	require.NoError(t, err)
+	client := &http.Client{Transport: rt}
+	defer client.CloseIdleConnections()
+
+	do := func() int {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, do())
+
+	// Rotation itself is handled inside client-go: because BearerTokenFile is
+	// set, the transport re-reads the file once its internal cache expires.
+	// That cache's TTL is undocumented and takes about a minute, so it is
+	// deliberately not exercised here.
+}
+
+func TestRestartCountingListerWatcher(t *testing.T) {
+	t.Parallel()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_watch_reestablished_total"})
+	lw := &restartCountingListerWatcher{
+		inner: &cache.ListWatch{
+			WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+				return watch.NewFake(), nil
+			},
+		},
+		counter: counter,
+	}
+	// The first watch establishes, only subsequent ones count as restarts.
+	_, err := lw.Watch(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 0.0, prom_testutil.ToFloat64(counter))
+	for i := 1; i <= 3; i++ {
+		_, err = lw.Watch(metav1.ListOptions{})
+		require.NoError(t, err)
+		require.Equal(t, float64(i), prom_testutil.ToFloat64(counter))
+	}
+}
+
 func TestRetryOnError(t *testing.T) {
 	t.Parallel()
 	for _, successAt := range []int{1, 2, 3} {
@@ -324,6 +936,36 @@ func TestFailuresCountMetric(t *testing.T) {
 	}
 }
 
+func TestWatchErrorsCountMetric(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"forbidden", apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("denied")), MetricReasonForbidden},
+		{"unauthorized", apierrors.NewUnauthorized("unauthorized"), MetricReasonUnauthorized},
+		{"other", errors.New("connection refused"), MetricReasonOther},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+			c.Discovery().(*fakediscovery.FakeDiscovery).PrependWatchReactor("*", func(_ kubetesting.Action) (bool, watch.Interface, error) {
+				return true, nil, tc.err
+			})
+
+			k8sDiscoveryTest{discovery: n}.Run(t)
+
+			got := prom_testutil.ToFloat64(n.metrics.watchErrorsCount.WithLabelValues(RolePod.String(), tc.reason))
+			require.GreaterOrEqual(t, got, float64(1))
+		})
+	}
+}
+
 func TestNodeName(t *testing.T) {
 	t.Parallel()
 	node := &apiv1.Node{
@@ -339,3 +981,293 @@ func TestNodeName(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "bar", name)
 }
+
+// TestNewKubeConfigBearerToken ensures that a kubeconfig using plain bearer
+// token authentication never selects a client auth provider plugin, so the
+// GCP plugin registered by the side-effect import cannot trigger
+// metadata-server lookups for such configurations.
+func TestNewKubeConfigBearerToken(t *testing.T) {
+	t.Parallel()
+	kubeConfig := `
+apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://localhost:8443
+  name: test
+contexts:
+- context:
+    cluster: test
+    user: test
+  name: test
+current-context: test
+users:
+- name: test
+  user:
+    token: abc123
+`
+	f := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(f, []byte(kubeConfig), 0o600))
+
+	cfg, err := clientcmd.BuildConfigFromFlags("", f)
+	require.NoError(t, err)
+	require.Equal(t, "abc123", cfg.BearerToken)
+	require.Nil(t, cfg.AuthProvider)
+	require.Nil(t, cfg.ExecProvider)
+
+	reg := prometheus.NewRegistry()
+	refreshMetrics := discovery.NewRefreshMetrics(reg)
+	metrics := newDiscovererMetrics("", reg, refreshMetrics)
+	require.NoError(t, metrics.Register())
+	defer metrics.Unregister()
+
+	d, err := New(promslog.NewNopLogger(), metrics, &SDConfig{Role: RolePod, KubeConfig: f})
+	require.NoError(t, err)
+	require.NotNil(t, d)
+}
+
+func TestNewMaxNamespaces(t *testing.T) {
+	t.Parallel()
+	kubeConfig := `
+apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://localhost:8443
+  name: test
+contexts:
+- context:
+    cluster: test
+    user: test
+  name: test
+current-context: test
+users:
+- name: test
+  user:
+    token: abc123
+`
+	f := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(f, []byte(kubeConfig), 0o600))
+
+	reg := prometheus.NewRegistry()
+	refreshMetrics := discovery.NewRefreshMetrics(reg)
+	metrics := newDiscovererMetrics("", reg, refreshMetrics)
+	require.NoError(t, metrics.Register())
+	defer metrics.Unregister()
+
+	conf := &SDConfig{
+		Role:               RolePod,
+		KubeConfig:         f,
+		NamespaceDiscovery: NamespaceDiscovery{Names: []string{"ns1", "ns2", "ns3"}},
+		MaxNamespaces:      2,
+	}
+	_, err := New(promslog.NewNopLogger(), metrics, conf)
+	require.ErrorContains(t, err, "exceeding 'max_namespaces' (2)")
+
+	conf.MaxNamespaces = 3
+	d, err := New(promslog.NewNopLogger(), metrics, conf)
+	require.NoError(t, err)
+	require.NotNil(t, d)
+}
+
+func TestNewKubeConfigContext(t *testing.T) {
+	t.Parallel()
+	kubeConfig := `
+apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://one.example.com:8443
+  name: one
+- cluster:
+    server: https://two.example.com:8443
+  name: two
+contexts:
+- context:
+    cluster: one
+    user: test
+  name: one
+- context:
+    cluster: two
+    user: test
+  name: two
+current-context: one
+users:
+- name: test
+  user:
+    token: abc123
+`
+	f := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(f, []byte(kubeConfig), 0o600))
+
+	reg := prometheus.NewRegistry()
+	refreshMetrics := discovery.NewRefreshMetrics(reg)
+	metrics := newDiscovererMetrics("", reg, refreshMetrics)
+	require.NoError(t, metrics.Register())
+	defer metrics.Unregister()
+
+	d, err := New(promslog.NewNopLogger(), metrics, &SDConfig{Role: RolePod, KubeConfig: f, KubeContext: "two"})
+	require.NoError(t, err)
+	require.NotNil(t, d)
+
+	_, err = New(promslog.NewNopLogger(), metrics, &SDConfig{Role: RolePod, KubeConfig: f, KubeContext: "three"})
+	require.ErrorContains(t, err, `context "three" does not exist`)
+}
+
+func TestMaxFailuresStopsDiscovery(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.maxFailures = 1
+	c.(*fake.Clientset).PrependReactor("list", "pods", func(kubetesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("pods are disabled")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ch := make(chan []*targetgroup.Group)
+	go func() {
+		for {
+			select {
+			case <-ch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx, ch)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("discovery did not stop after exhausting its failure budget")
+	}
+}
+
+func TestCacheSyncedMetric(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	obj := makePods("default")
+	c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+
+	k8sDiscoveryTest{
+		discovery:        n,
+		expectedMaxItems: 1,
+		expectedRes:      expectedPodTargetGroups("default"),
+	}.Run(t)
+
+	require.Eventually(t, func() bool {
+		return prom_testutil.ToFloat64(n.metrics.cacheSynced.WithLabelValues(RolePod.String())) == 1
+	}, time.Second, 10*time.Millisecond, "cache_synced gauge was not set after informers synced")
+}
+
+// TestAllowWatchBookmarks verifies that a Bookmark event on the watch only
+// advances the reflector's resume position and never surfaces as a target.
+func TestAllowWatchBookmarks(t *testing.T) {
+	t.Parallel()
+	require.True(t, DefaultSDConfig.AllowWatchBookmarks)
+
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.allowWatchBookmarks = true
+
+	fw := watch.NewFakeWithChanSize(2, false)
+	c.(*fake.Clientset).PrependWatchReactor("pods", kubetesting.DefaultWatchReactor(fw, nil))
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			fw.Add(obj)
+			fw.Action(watch.Bookmark, &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{ResourceVersion: "100"},
+			})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expectedPodTargetGroups("default"),
+	}.Run(t)
+}
+
+func TestClientConfigError(t *testing.T) {
+	t.Parallel()
+
+	// Outside a cluster with neither api_server nor kubeconfig_file set, the
+	// in-cluster configuration is unavailable.
+	_, _, err := buildRestConfig(promslog.NewNopLogger(), &SDConfig{Role: RolePod})
+	var cerr *ClientConfigError
+	require.ErrorAs(t, err, &cerr)
+	require.Empty(t, cerr.KubeConfig)
+	require.Contains(t, err.Error(), "no APIServer/kubeconfig set and not running in-cluster")
+
+	// A kubeconfig file that cannot be loaded names the offending path.
+	_, _, err = buildRestConfig(promslog.NewNopLogger(), &SDConfig{Role: RolePod, KubeConfig: "testdata/nonexistent-kubeconfig"})
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, "testdata/nonexistent-kubeconfig", cerr.KubeConfig)
+	require.Contains(t, err.Error(), `cannot load kubeconfig "testdata/nonexistent-kubeconfig"`)
+}
+
+// TestMaxConcurrentInformerStart runs a multi-namespace discovery with a
+// single informer start slot and verifies all informers still sync and emit.
+func TestMaxConcurrentInformerStart(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{Names: []string{"ns1", "ns2", "ns3"}})
+	n.informerStartSem = make(chan struct{}, 1)
+
+	expected := map[string]*targetgroup.Group{}
+	for _, ns := range []string{"ns1", "ns2", "ns3"} {
+		for k, v := range expectedPodTargetGroups(ns) {
+			expected[k] = v
+		}
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			for _, ns := range []string{"ns1", "ns2", "ns3"} {
+				obj := makePods(ns)
+				c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+			}
+		},
+		expectedMaxItems: 3,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestLabelHook(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.LabelHook = func(role Role, meta metav1.ObjectMeta, ls model.LabelSet) {
+		ls["hook_role"] = lv(string(role))
+		ls["hook_object"] = lv(meta.Namespace + "/" + meta.Name)
+	}
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["hook_role"] = "pod"
+		tg.Labels["hook_object"] = "default/testpod"
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestSourceObjectMeta(t *testing.T) {
+	t.Parallel()
+	for source, want := range map[string]metav1.ObjectMeta{
+		"node/testnode":                         {Name: "testnode"},
+		"pod/default/testpod":                   {Namespace: "default", Name: "testpod"},
+		"configmap/default/targets/3":           {Namespace: "default", Name: "targets"},
+		"endpointslice/default/service/testsvc": {Namespace: "default", Name: "testsvc"},
+	} {
+		require.Equal(t, want, sourceObjectMeta(source), "source %q", source)
+	}
+}
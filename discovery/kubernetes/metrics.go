@@ -22,17 +22,27 @@ import (
 var _ discovery.DiscovererMetrics = (*kubernetesMetrics)(nil)
 
 type kubernetesMetrics struct {
-	eventCount    *prometheus.CounterVec
-	failuresCount prometheus.Counter
+	eventCount              *prometheus.CounterVec
+	failuresCount           prometheus.Counter
+	watchErrorsCount        *prometheus.CounterVec
+	watchReestablishedCount *prometheus.CounterVec
+	cacheSynced             *prometheus.GaugeVec
+	cacheObjects            *prometheus.GaugeVec
+	targetsCount            *prometheus.GaugeVec
 
 	metricRegisterer discovery.MetricRegisterer
 }
 
-func newDiscovererMetrics(reg prometheus.Registerer, _ discovery.RefreshMetricsInstantiator) discovery.DiscovererMetrics {
+// newDiscovererMetrics builds the metrics under the given namespace prefix,
+// defaulting to prometheus_sd_kubernetes when it is empty.
+func newDiscovererMetrics(namespace string, reg prometheus.Registerer, _ discovery.RefreshMetricsInstantiator) discovery.DiscovererMetrics {
+	if namespace == "" {
+		namespace = discovery.KubernetesMetricsNamespace
+	}
 	m := &kubernetesMetrics{
 		eventCount: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Namespace: discovery.KubernetesMetricsNamespace,
+				Namespace: namespace,
 				Name:      "events_total",
 				Help:      "The number of Kubernetes events handled.",
 			},
@@ -40,16 +50,61 @@ func newDiscovererMetrics(reg prometheus.Registerer, _ discovery.RefreshMetricsI
 		),
 		failuresCount: prometheus.NewCounter(
 			prometheus.CounterOpts{
-				Namespace: discovery.KubernetesMetricsNamespace,
+				Namespace: namespace,
 				Name:      "failures_total",
 				Help:      "The number of failed WATCH/LIST requests.",
 			},
 		),
+		watchErrorsCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "watch_errors_total",
+				Help:      "The number of failed WATCH/LIST requests by error class, so that permission errors can be told apart from transient failures.",
+			},
+			[]string{"role", "reason"},
+		),
+		watchReestablishedCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "watch_reestablished_total",
+				Help:      "The number of times a WATCH had to be re-established after the initial one, indicating flapping watches when it grows quickly.",
+			},
+			[]string{"role"},
+		),
+		cacheSynced: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "cache_synced",
+				Help:      "1 once all informer caches for the role have synced, 0 otherwise.",
+			},
+			[]string{"role"},
+		),
+		cacheObjects: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "cache_objects",
+				Help:      "The number of objects held in the informer caches, by resource kind.",
+			},
+			[]string{"role", "kind"},
+		),
+		targetsCount: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "targets",
+				Help:      "The number of targets currently emitted for the role.",
+			},
+			[]string{"role"},
+		),
 	}
 
 	m.metricRegisterer = discovery.NewMetricRegisterer(reg, []prometheus.Collector{
 		m.eventCount,
 		m.failuresCount,
+		m.watchErrorsCount,
+		m.watchReestablishedCount,
+		m.cacheSynced,
+		m.cacheObjects,
+		m.targetsCount,
 	})
 
 	// Initialize metric vectors.
@@ -60,6 +115,12 @@ func newDiscovererMetrics(reg prometheus.Registerer, _ discovery.RefreshMetricsI
 		RolePod.String(),
 		RoleService.String(),
 		RoleIngress.String(),
+		RoleCronJob.String(),
+		RolePVC.String(),
+		RoleCustomResource.String(),
+		RoleServiceImport.String(),
+		RoleConfigMap.String(),
+		RoleAPIServer.String(),
 	} {
 		for _, evt := range []string{
 			MetricLabelRoleAdd,
@@ -68,6 +129,16 @@ func newDiscovererMetrics(reg prometheus.Registerer, _ discovery.RefreshMetricsI
 		} {
 			m.eventCount.WithLabelValues(role, evt)
 		}
+		for _, reason := range []string{
+			MetricReasonForbidden,
+			MetricReasonUnauthorized,
+			MetricReasonOther,
+		} {
+			m.watchErrorsCount.WithLabelValues(role, reason)
+		}
+		m.watchReestablishedCount.WithLabelValues(role)
+		m.cacheSynced.WithLabelValues(role)
+		m.targetsCount.WithLabelValues(role)
 	}
 
 	m.failuresCount.Add(0)
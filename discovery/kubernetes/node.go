@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -38,14 +40,18 @@ const (
 
 // Node discovers Kubernetes nodes.
 type Node struct {
-	logger   *slog.Logger
-	informer cache.SharedInformer
-	store    cache.Store
-	queue    *workqueue.Type
+	logger          *slog.Logger
+	informer        cache.SharedInformer
+	store           cache.Store
+	queue           *workqueue.Type
+	splitEndpoints  bool
+	addressPriority []nodeAddressPreference
+	excludeTaints   []string
+	withCapacity    bool
 }
 
 // NewNode returns a new node discovery.
-func NewNode(l *slog.Logger, inf cache.SharedInformer, eventCount *prometheus.CounterVec) *Node {
+func NewNode(l *slog.Logger, inf cache.SharedInformer, eventCount *prometheus.CounterVec, splitEndpoints bool, addressPriority []nodeAddressPreference, excludeTaints []string, withCapacity bool) *Node {
 	if l == nil {
 		l = promslog.NewNopLogger()
 	}
@@ -55,10 +61,14 @@ func NewNode(l *slog.Logger, inf cache.SharedInformer, eventCount *prometheus.Co
 	nodeDeleteCount := eventCount.WithLabelValues(RoleNode.String(), MetricLabelRoleDelete)
 
 	n := &Node{
-		logger:   l,
-		informer: inf,
-		store:    inf.GetStore(),
-		queue:    workqueue.NewNamed(RoleNode.String()),
+		logger:          l,
+		informer:        inf,
+		store:           inf.GetStore(),
+		queue:           workqueue.NewNamed(RoleNode.String()),
+		splitEndpoints:  splitEndpoints,
+		addressPriority: addressPriority,
+		excludeTaints:   excludeTaints,
+		withCapacity:    withCapacity,
 	}
 
 	_, err := n.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -136,6 +146,12 @@ func (n *Node) process(ctx context.Context, ch chan<- []*targetgroup.Group) bool
 		n.logger.Error("converting to Node object failed", "err", err)
 		return true
 	}
+	if nodeHasExcludedTaint(node, n.excludeTaints) {
+		// Treated like a deletion so that the target disappears when the
+		// taint is added to an already discovered node.
+		send(ctx, ch, &targetgroup.Group{Source: nodeSource(node)})
+		return true
+	}
 	send(ctx, ch, n.buildNode(node))
 	return true
 }
@@ -158,8 +174,23 @@ func nodeSourceFromName(name string) string {
 }
 
 const (
-	nodeProviderIDLabel = metaLabelPrefix + "node_provider_id"
-	nodeAddressPrefix   = metaLabelPrefix + "node_address_"
+	nodeProviderIDLabel       = metaLabelPrefix + "node_provider_id"
+	nodeReadyLabel            = metaLabelPrefix + "node_ready"
+	nodeUnschedulableLabel    = metaLabelPrefix + "node_unschedulable"
+	nodeKubeletVersionLabel   = metaLabelPrefix + "node_kubelet_version"
+	nodeOSLabel               = metaLabelPrefix + "node_os"
+	nodeArchLabel             = metaLabelPrefix + "node_arch"
+	nodeContainerRuntimeLabel = metaLabelPrefix + "node_container_runtime"
+	nodeTaintPrefix           = metaLabelPrefix + "node_taint_"
+	nodeRegionLabel           = metaLabelPrefix + "node_region"
+	nodeZoneLabel             = metaLabelPrefix + "node_zone"
+	nodeCapacityPrefix        = metaLabelPrefix + "node_capacity_"
+	nodeAllocatablePrefix     = metaLabelPrefix + "node_allocatable_"
+	nodeAddressPrefix         = metaLabelPrefix + "node_address_"
+	nodeEndpointKindLabel     = metaLabelPrefix + "node_endpoint_kind"
+
+	nodeEndpointKindKubelet  = model.LabelValue("kubelet")
+	nodeEndpointKindCadvisor = model.LabelValue("cadvisor")
 )
 
 func nodeLabels(n *apiv1.Node) model.LabelSet {
@@ -167,19 +198,113 @@ func nodeLabels(n *apiv1.Node) model.LabelSet {
 	ls := make(model.LabelSet)
 
 	ls[nodeProviderIDLabel] = lv(n.Spec.ProviderID)
+	ls[nodeUnschedulableLabel] = lv(strconv.FormatBool(n.Spec.Unschedulable))
+
+	// The node info is reported by the kubelet and may not be populated yet;
+	// it changes in place when a node is upgraded.
+	for label, value := range map[model.LabelName]string{
+		nodeKubeletVersionLabel:   n.Status.NodeInfo.KubeletVersion,
+		nodeOSLabel:               n.Status.NodeInfo.OperatingSystem,
+		nodeArchLabel:             n.Status.NodeInfo.Architecture,
+		nodeContainerRuntimeLabel: n.Status.NodeInfo.ContainerRuntimeVersion,
+	} {
+		if value != "" {
+			ls[label] = lv(value)
+		}
+	}
+
+	// Normalized copies of the well-known topology labels, so zone-aware
+	// relabeling does not have to reconstruct them from the mangled raw label
+	// names. Older clusters may still carry the deprecated beta variants.
+	if region, ok := n.Labels[apiv1.LabelTopologyRegion]; ok {
+		ls[nodeRegionLabel] = lv(region)
+	} else if region, ok := n.Labels[apiv1.LabelFailureDomainBetaRegion]; ok {
+		ls[nodeRegionLabel] = lv(region)
+	}
+	if zone, ok := n.Labels[apiv1.LabelTopologyZone]; ok {
+		ls[nodeZoneLabel] = lv(zone)
+	} else if zone, ok := n.Labels[apiv1.LabelFailureDomainBetaZone]; ok {
+		ls[nodeZoneLabel] = lv(zone)
+	}
+
+	for _, cond := range n.Status.Conditions {
+		if cond.Type == apiv1.NodeReady {
+			ls[nodeReadyLabel] = lv(strings.ToLower(string(cond.Status)))
+			break
+		}
+	}
+
+	// The effect is appended to the label name uniformly for all effects, so
+	// NoSchedule and NoExecute taints with the same key stay distinguishable.
+	for _, taint := range n.Spec.Taints {
+		name := strutil.SanitizeLabelName(nodeTaintPrefix + taint.Key + "_" + strings.ToLower(string(taint.Effect)))
+		ls[model.LabelName(name)] = lv(taint.Value)
+	}
 
 	addObjectMetaLabels(ls, n.ObjectMeta, RoleNode)
 
 	return ls
 }
 
+// addNodeResourceLabels attaches the cpu, memory and pods quantities of the
+// given resource list, normalized to base units: cores for cpu (fractional
+// for millicore quantities), bytes for memory and a plain count for pods.
+func addNodeResourceLabels(ls model.LabelSet, prefix string, resources apiv1.ResourceList) {
+	for resourceName, label := range map[apiv1.ResourceName]string{
+		apiv1.ResourceCPU:    "cpu",
+		apiv1.ResourceMemory: "memory",
+		apiv1.ResourcePods:   "pods",
+	} {
+		quantity, ok := resources[resourceName]
+		if !ok {
+			continue
+		}
+		var value string
+		if resourceName == apiv1.ResourceCPU {
+			value = strconv.FormatFloat(quantity.AsApproximateFloat64(), 'f', -1, 64)
+		} else {
+			value = strconv.FormatInt(quantity.Value(), 10)
+		}
+		ls[model.LabelName(prefix+label)] = lv(value)
+	}
+}
+
+// nodeHasExcludedTaint reports whether the node carries a taint with one of
+// the excluded keys, regardless of the taint's value or effect.
+func nodeHasExcludedTaint(node *apiv1.Node, excludeTaints []string) bool {
+	for _, taint := range node.Spec.Taints {
+		if slices.Contains(excludeTaints, taint.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeExcludedByTaint reports whether the named node is known to the informer
+// and carries one of the excluded taint keys. Unknown nodes are kept.
+func nodeExcludedByTaint(nodeInf cache.SharedInformer, nodeName string, excludeTaints []string) bool {
+	if len(excludeTaints) == 0 || nodeName == "" {
+		return false
+	}
+	obj, exists, err := nodeInf.GetStore().GetByKey(nodeName)
+	if err != nil || !exists {
+		return false
+	}
+	return nodeHasExcludedTaint(obj.(*apiv1.Node), excludeTaints)
+}
+
 func (n *Node) buildNode(node *apiv1.Node) *targetgroup.Group {
 	tg := &targetgroup.Group{
 		Source: nodeSource(node),
 	}
 	tg.Labels = nodeLabels(node)
 
-	addr, addrMap, err := nodeAddress(node)
+	if n.withCapacity {
+		addNodeResourceLabels(tg.Labels, nodeCapacityPrefix, node.Status.Capacity)
+		addNodeResourceLabels(tg.Labels, nodeAllocatablePrefix, node.Status.Allocatable)
+	}
+
+	addr, addrMap, err := nodeAddressWithPriority(node, n.addressPriority)
 	if err != nil {
 		n.logger.Warn("No node address found", "err", err)
 		return nil
@@ -195,7 +320,16 @@ func (n *Node) buildNode(node *apiv1.Node) *targetgroup.Group {
 		ln := strutil.SanitizeLabelName(nodeAddressPrefix + string(ty))
 		t[model.LabelName(ln)] = lv(a[0])
 	}
-	tg.Targets = append(tg.Targets, t)
+
+	if n.splitEndpoints {
+		// Emit one target per endpoint exposed by the kubelet, so a single
+		// scrape config can relabel the metrics path based on the kind.
+		for _, kind := range []model.LabelValue{nodeEndpointKindKubelet, nodeEndpointKindCadvisor} {
+			tg.Targets = append(tg.Targets, t.Merge(model.LabelSet{nodeEndpointKindLabel: kind}))
+		}
+	} else {
+		tg.Targets = append(tg.Targets, t)
+	}
 
 	return tg
 }
@@ -210,28 +344,91 @@ func (n *Node) buildNode(node *apiv1.Node) *targetgroup.Group {
 //
 // Derived from k8s.io/kubernetes/pkg/util/node/node.go.
 func nodeAddress(node *apiv1.Node) (string, map[apiv1.NodeAddressType][]string, error) {
-	m := map[apiv1.NodeAddressType][]string{}
-	for _, a := range node.Status.Addresses {
-		m[a.Type] = append(m[a.Type], a.Address)
+	return nodeAddressWithPriority(node, nil)
+}
+
+// nodeAddressPreference is one entry of the node_address_priority option: a
+// node address type, optionally restricted to a single IP family.
+type nodeAddressPreference struct {
+	addressType apiv1.NodeAddressType
+	family      int // 0 means any family, otherwise 4 or 6.
+}
+
+// defaultNodeAddressPriority mirrors the historical fixed priority of
+// nodeAddress.
+var defaultNodeAddressPriority = []nodeAddressPreference{
+	{addressType: apiv1.NodeInternalIP},
+	{addressType: apiv1.NodeInternalDNS},
+	{addressType: apiv1.NodeExternalIP},
+	{addressType: apiv1.NodeExternalDNS},
+	{addressType: apiv1.NodeAddressType(NodeLegacyHostIP)},
+	{addressType: apiv1.NodeHostName},
+}
+
+// parseNodeAddressPriority validates and parses the node_address_priority
+// option. Each entry is a node address type, optionally suffixed with /IPv4
+// or /IPv6 to restrict it to one IP family, e.g. "InternalIP/IPv6".
+func parseNodeAddressPriority(priority []string) ([]nodeAddressPreference, error) {
+	var prefs []nodeAddressPreference
+	for _, p := range priority {
+		typ, family, _ := strings.Cut(p, "/")
+		switch typ {
+		case string(apiv1.NodeInternalIP), string(apiv1.NodeInternalDNS), string(apiv1.NodeExternalIP),
+			string(apiv1.NodeExternalDNS), NodeLegacyHostIP, string(apiv1.NodeHostName):
+		default:
+			return nil, fmt.Errorf("unknown node address type %q in 'node_address_priority'", typ)
+		}
+		pref := nodeAddressPreference{addressType: apiv1.NodeAddressType(typ)}
+		switch family {
+		case "":
+		case "IPv4":
+			pref.family = 4
+		case "IPv6":
+			pref.family = 6
+		default:
+			return nil, fmt.Errorf("invalid IP family %q in 'node_address_priority', expecting IPv4 or IPv6", family)
+		}
+		if pref.family != 0 {
+			switch typ {
+			case string(apiv1.NodeInternalIP), string(apiv1.NodeExternalIP), NodeLegacyHostIP:
+			default:
+				return nil, fmt.Errorf("IP family restriction in 'node_address_priority' is not applicable to address type %q", typ)
+			}
+		}
+		prefs = append(prefs, pref)
 	}
+	return prefs, nil
+}
 
-	if addresses, ok := m[apiv1.NodeInternalIP]; ok {
-		return addresses[0], m, nil
+func matchesIPFamily(address string, family int) bool {
+	if family == 0 {
+		return true
 	}
-	if addresses, ok := m[apiv1.NodeInternalDNS]; ok {
-		return addresses[0], m, nil
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
 	}
-	if addresses, ok := m[apiv1.NodeExternalIP]; ok {
-		return addresses[0], m, nil
+	if family == 4 {
+		return ip.To4() != nil
 	}
-	if addresses, ok := m[apiv1.NodeExternalDNS]; ok {
-		return addresses[0], m, nil
+	return ip.To4() == nil
+}
+
+func nodeAddressWithPriority(node *apiv1.Node, priority []nodeAddressPreference) (string, map[apiv1.NodeAddressType][]string, error) {
+	m := map[apiv1.NodeAddressType][]string{}
+	for _, a := range node.Status.Addresses {
+		m[a.Type] = append(m[a.Type], a.Address)
 	}
-	if addresses, ok := m[apiv1.NodeAddressType(NodeLegacyHostIP)]; ok {
-		return addresses[0], m, nil
+
+	if len(priority) == 0 {
+		priority = defaultNodeAddressPriority
 	}
-	if addresses, ok := m[apiv1.NodeHostName]; ok {
-		return addresses[0], m, nil
+	for _, pref := range priority {
+		for _, address := range m[pref.addressType] {
+			if matchesIPFamily(address, pref.family) {
+				return address, m, nil
+			}
+		}
 	}
 	return "", m, errors.New("host address unknown")
 }
@@ -19,7 +19,9 @@ import (
 	"testing"
 
 	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/prometheus/prometheus/discovery/targetgroup"
@@ -84,6 +86,7 @@ func TestNodeDiscoveryBeforeStart(t *testing.T) {
 				Labels: model.LabelSet{
 					"__meta_kubernetes_node_name":                              "test",
 					"__meta_kubernetes_node_provider_id":                       "aws:///nl-north-7b/i-03149834983492827",
+					"__meta_kubernetes_node_unschedulable":                     "false",
 					"__meta_kubernetes_node_label_test_label":                  "testvalue",
 					"__meta_kubernetes_node_labelpresent_test_label":           "true",
 					"__meta_kubernetes_node_annotation_test_annotation":        "testannotationvalue",
@@ -95,6 +98,366 @@ func TestNodeDiscoveryBeforeStart(t *testing.T) {
 	}.Run(t)
 }
 
+func TestNodeDiscoveryTaints(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			obj.Spec.Taints = []v1.Taint{
+				{Key: "nvidia.com/gpu", Value: "present", Effect: v1.TaintEffectNoSchedule},
+				{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute},
+			}
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":                                           "test1",
+					"__meta_kubernetes_node_provider_id":                                    "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable":                                  "false",
+					"__meta_kubernetes_node_taint_nvidia_com_gpu_noschedule":                "present",
+					"__meta_kubernetes_node_taint_node_kubernetes_io_unreachable_noexecute": "",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+func TestNodeDiscoveryExcludeTaints(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+	n.excludeNodeTaints = []string{"nvidia.com/gpu"}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		afterStart: func() {
+			// Adding an excluded taint drops the already discovered target.
+			obj := makeEnumeratedNode(1)
+			obj.Spec.Taints = []v1.Taint{
+				{Key: "nvidia.com/gpu", Value: "present", Effect: v1.TaintEffectNoSchedule},
+			}
+			c.CoreV1().Nodes().Update(context.Background(), obj, metav1.UpdateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+func TestNodeDiscoveryNodeInfo(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			obj.Status.NodeInfo = v1.NodeSystemInfo{
+				KubeletVersion:          "v1.29.3",
+				OperatingSystem:         "linux",
+				Architecture:            "arm64",
+				ContainerRuntimeVersion: "containerd://1.7.13",
+			}
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":              "test1",
+					"__meta_kubernetes_node_provider_id":       "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable":     "false",
+					"__meta_kubernetes_node_kubelet_version":   "v1.29.3",
+					"__meta_kubernetes_node_os":                "linux",
+					"__meta_kubernetes_node_arch":              "arm64",
+					"__meta_kubernetes_node_container_runtime": "containerd://1.7.13",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+func TestNodeDiscoveryRegionZone(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeNode("test1", "1.2.3.4", "aws:///de-west-3a/i-1", map[string]string{
+				"topology.kubernetes.io/region": "de-west-3",
+				"topology.kubernetes.io/zone":   "de-west-3a",
+			}, map[string]string{})
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":                                       "test1",
+					"__meta_kubernetes_node_provider_id":                                "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable":                              "false",
+					"__meta_kubernetes_node_region":                                     "de-west-3",
+					"__meta_kubernetes_node_zone":                                       "de-west-3a",
+					"__meta_kubernetes_node_label_topology_kubernetes_io_region":        "de-west-3",
+					"__meta_kubernetes_node_labelpresent_topology_kubernetes_io_region": "true",
+					"__meta_kubernetes_node_label_topology_kubernetes_io_zone":          "de-west-3a",
+					"__meta_kubernetes_node_labelpresent_topology_kubernetes_io_zone":   "true",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+func TestNodeDiscoveryAddressPriorityIPv6(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+	prefs, err := parseNodeAddressPriority([]string{"InternalIP/IPv6", "InternalIP"})
+	require.NoError(t, err)
+	n.nodeAddressPriority = prefs
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			obj.Status.Addresses = append(obj.Status.Addresses, v1.NodeAddress{
+				Type:    v1.NodeInternalIP,
+				Address: "2001:db8::1",
+			})
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "[2001:db8::1]:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":          "test1",
+					"__meta_kubernetes_node_provider_id":   "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable": "false",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+// TestNodeDiscoveryAddressPriorityHostname covers clusters whose nodes only
+// expose a resolvable Hostname address, e.g. air-gapped environments.
+func TestNodeDiscoveryAddressPriorityHostname(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+	prefs, err := parseNodeAddressPriority([]string{"Hostname"})
+	require.NoError(t, err)
+	n.nodeAddressPriority = prefs
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			obj.Status.Addresses = []v1.NodeAddress{
+				{
+					Type:    v1.NodeHostName,
+					Address: "node-1.internal",
+				},
+			}
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "node-1.internal:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_Hostname": "node-1.internal",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":          "test1",
+					"__meta_kubernetes_node_provider_id":   "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable": "false",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+func TestParseNodeAddressPriority(t *testing.T) {
+	t.Parallel()
+	_, err := parseNodeAddressPriority([]string{"InternalDNS", "InternalIP/IPv4"})
+	require.NoError(t, err)
+	_, err = parseNodeAddressPriority([]string{"Bogus"})
+	require.EqualError(t, err, `unknown node address type "Bogus" in 'node_address_priority'`)
+	_, err = parseNodeAddressPriority([]string{"InternalIP/IPvX"})
+	require.EqualError(t, err, `invalid IP family "IPvX" in 'node_address_priority', expecting IPv4 or IPv6`)
+	_, err = parseNodeAddressPriority([]string{"InternalDNS/IPv6"})
+	require.EqualError(t, err, `IP family restriction in 'node_address_priority' is not applicable to address type "InternalDNS"`)
+}
+
+func TestNodeDiscoveryReady(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			obj.Status.Conditions = []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionFalse},
+			}
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":          "test1",
+					"__meta_kubernetes_node_provider_id":   "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable": "false",
+					"__meta_kubernetes_node_ready":         "false",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+func TestNodeDiscoverySplitEndpoints(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+	n.splitNodeEndpoints = true
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+						"__meta_kubernetes_node_endpoint_kind":      "kubelet",
+					},
+					{
+						"__address__": "1.2.3.4:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+						"__meta_kubernetes_node_endpoint_kind":      "cadvisor",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":          "test1",
+					"__meta_kubernetes_node_provider_id":   "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable": "false",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
+func TestNodeDiscoveryCapacity(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
+	n.attachMetadata.NodeCapacity = true
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeEnumeratedNode(1)
+			obj.Status.Capacity = v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("16Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			}
+			obj.Status.Allocatable = v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("3500m"),
+				v1.ResourceMemory: resource.MustParse("15Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			}
+			c.CoreV1().Nodes().Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"node/test1": {
+				Targets: []model.LabelSet{
+					{
+						"__address__": "1.2.3.4:10250",
+						"instance":    "test1",
+						"__meta_kubernetes_node_address_InternalIP": "1.2.3.4",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_node_name":               "test1",
+					"__meta_kubernetes_node_provider_id":        "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable":      "false",
+					"__meta_kubernetes_node_capacity_cpu":       "4",
+					"__meta_kubernetes_node_capacity_memory":    "17179869184",
+					"__meta_kubernetes_node_capacity_pods":      "110",
+					"__meta_kubernetes_node_allocatable_cpu":    "3.5",
+					"__meta_kubernetes_node_allocatable_memory": "16106127360",
+					"__meta_kubernetes_node_allocatable_pods":   "110",
+				},
+				Source: "node/test1",
+			},
+		},
+	}.Run(t)
+}
+
 func TestNodeDiscoveryAdd(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RoleNode, NamespaceDiscovery{})
@@ -116,8 +479,9 @@ func TestNodeDiscoveryAdd(t *testing.T) {
 					},
 				},
 				Labels: model.LabelSet{
-					"__meta_kubernetes_node_name":        "test1",
-					"__meta_kubernetes_node_provider_id": "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_name":          "test1",
+					"__meta_kubernetes_node_provider_id":   "aws:///de-west-3a/i-1",
+					"__meta_kubernetes_node_unschedulable": "false",
 				},
 				Source: "node/test1",
 			},
@@ -177,6 +541,7 @@ func TestNodeDiscoveryUpdate(t *testing.T) {
 					"__meta_kubernetes_node_labelpresent_Unschedulable": "true",
 					"__meta_kubernetes_node_name":                       "test0",
 					"__meta_kubernetes_node_provider_id":                "aws:///fr-south-1c/i-49508290343823952",
+					"__meta_kubernetes_node_unschedulable":              "false",
 				},
 				Source: "node/test0",
 			},
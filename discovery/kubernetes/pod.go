@@ -15,22 +15,27 @@ package kubernetes
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/promslog"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/util/strutil"
 )
 
 const (
@@ -45,13 +50,32 @@ type Pod struct {
 	withNodeMetadata      bool
 	namespaceInf          cache.SharedInformer
 	withNamespaceMetadata bool
-	store                 cache.Store
-	logger                *slog.Logger
-	queue                 *workqueue.Type
+	replicaSetInf         cache.SharedInformer
+	withOwnerWorkload     bool
+	withVolumeMounts      bool
+	withSpecHash          bool
+	defaultMetricsPort    int
+	onlyNamedPorts        bool
+	targetsPerPod         bool
+	portAnnotation        string
+	requirePodIP          bool
+	// emitted records the sources already sent downstream while requirePodIP
+	// is enabled, so that a pod losing its IP again, e.g. on eviction, clears
+	// its stale targets instead of being held back.
+	emitted                map[string]struct{}
+	excludeTerminating     bool
+	leaseInf               cache.SharedInformer
+	withLeaderLease        bool
+	leaderAnnotation       string
+	excludeNodeTaints      []string
+	namespaceLabelPrefixes []string
+	store                  cache.Store
+	logger                 *slog.Logger
+	queue                  *workqueue.Type
 }
 
 // NewPod creates a new pod discovery.
-func NewPod(l *slog.Logger, pods cache.SharedIndexInformer, nodes, namespace cache.SharedInformer, eventCount *prometheus.CounterVec) *Pod {
+func NewPod(l *slog.Logger, pods cache.SharedIndexInformer, nodes, namespace, replicaSet cache.SharedInformer, eventCount *prometheus.CounterVec, includeVolumeMounts, includeSpecHash bool, defaultMetricsPort int, onlyNamedPorts, targetsPerPod bool, portAnnotation string, requirePodIP, excludeTerminating bool, excludeNodeTaints, namespaceLabelPrefixes []string, lease cache.SharedInformer, leaderAnnotation string) *Pod {
 	if l == nil {
 		l = promslog.NewNopLogger()
 	}
@@ -61,14 +85,30 @@ func NewPod(l *slog.Logger, pods cache.SharedIndexInformer, nodes, namespace cac
 	podUpdateCount := eventCount.WithLabelValues(RolePod.String(), MetricLabelRoleUpdate)
 
 	p := &Pod{
-		podInf:                pods,
-		nodeInf:               nodes,
-		withNodeMetadata:      nodes != nil,
-		namespaceInf:          namespace,
-		withNamespaceMetadata: namespace != nil,
-		store:                 pods.GetStore(),
-		logger:                l,
-		queue:                 workqueue.NewNamed(RolePod.String()),
+		podInf:                 pods,
+		nodeInf:                nodes,
+		withNodeMetadata:       nodes != nil,
+		namespaceInf:           namespace,
+		withNamespaceMetadata:  namespace != nil,
+		replicaSetInf:          replicaSet,
+		withOwnerWorkload:      replicaSet != nil,
+		withVolumeMounts:       includeVolumeMounts,
+		withSpecHash:           includeSpecHash,
+		defaultMetricsPort:     defaultMetricsPort,
+		onlyNamedPorts:         onlyNamedPorts,
+		targetsPerPod:          targetsPerPod,
+		portAnnotation:         portAnnotation,
+		requirePodIP:           requirePodIP,
+		emitted:                map[string]struct{}{},
+		excludeTerminating:     excludeTerminating,
+		leaseInf:               lease,
+		withLeaderLease:        lease != nil,
+		leaderAnnotation:       leaderAnnotation,
+		excludeNodeTaints:      excludeNodeTaints,
+		namespaceLabelPrefixes: namespaceLabelPrefixes,
+		store:                  pods.GetStore(),
+		logger:                 l,
+		queue:                  workqueue.NewNamed(RolePod.String()),
 	}
 	_, err := p.podInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(o interface{}) {
@@ -125,9 +165,60 @@ func NewPod(l *slog.Logger, pods cache.SharedIndexInformer, nodes, namespace cac
 		}
 	}
 
+	if p.withLeaderLease {
+		_, err = p.leaseInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(o interface{}) {
+				p.enqueueLeaseHolder(o)
+			},
+			UpdateFunc: func(old, o interface{}) {
+				// The pod losing the leadership needs re-labeling as much as
+				// the one gaining it.
+				p.enqueueLeaseHolder(old)
+				p.enqueueLeaseHolder(o)
+			},
+			DeleteFunc: func(o interface{}) {
+				p.enqueueLeaseHolder(o)
+			},
+		})
+		if err != nil {
+			l.Error("Error adding leases event handler.", "err", err)
+		}
+	}
+
 	return p
 }
 
+// enqueueLeaseHolder re-enqueues the pod named by a lease's holderIdentity.
+func (p *Pod) enqueueLeaseHolder(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	lease, ok := obj.(*coordinationv1.Lease)
+	if !ok {
+		return
+	}
+	pod, ok := leaseHolderPodName(lease)
+	if !ok {
+		return
+	}
+	key := namespacedName(lease.Namespace, pod)
+	if _, exists, err := p.store.GetByKey(key); err == nil && exists {
+		p.queue.Add(key)
+	}
+}
+
+// leaseHolderPodName extracts the pod named by a lease's holderIdentity,
+// which by the common client-go convention is "<pod>_<id>" or the bare pod
+// name.
+func leaseHolderPodName(lease *coordinationv1.Lease) (string, bool) {
+	holder := lease.Spec.HolderIdentity
+	if holder == nil || *holder == "" {
+		return "", false
+	}
+	pod, _, _ := strings.Cut(*holder, "_")
+	return pod, true
+}
+
 func (p *Pod) enqueue(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
@@ -148,6 +239,12 @@ func (p *Pod) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
 	if p.withNamespaceMetadata {
 		cacheSyncs = append(cacheSyncs, p.namespaceInf.HasSynced)
 	}
+	if p.withOwnerWorkload {
+		cacheSyncs = append(cacheSyncs, p.replicaSetInf.HasSynced)
+	}
+	if p.withLeaderLease {
+		cacheSyncs = append(cacheSyncs, p.leaseInf.HasSynced)
+	}
 
 	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
 		if !errors.Is(ctx.Err(), context.Canceled) {
@@ -183,7 +280,9 @@ func (p *Pod) process(ctx context.Context, ch chan<- []*targetgroup.Group) bool
 		return true
 	}
 	if !exists {
-		send(ctx, ch, &targetgroup.Group{Source: podSourceFromNamespaceAndName(namespace, name)})
+		source := podSourceFromNamespaceAndName(namespace, name)
+		delete(p.emitted, source)
+		send(ctx, ch, &targetgroup.Group{Source: source})
 		return true
 	}
 	pod, err := convertToPod(o)
@@ -191,7 +290,15 @@ func (p *Pod) process(ctx context.Context, ch chan<- []*targetgroup.Group) bool
 		p.logger.Error("converting to Pod object failed", "err", err)
 		return true
 	}
-	send(ctx, ch, p.buildPod(pod))
+	tg := p.buildPod(pod)
+	if p.requirePodIP {
+		if tg == nil {
+			// Held back until the pod has an IP; nothing was emitted yet.
+			return true
+		}
+		p.emitted[tg.Source] = struct{}{}
+	}
+	send(ctx, ch, tg)
 	return true
 }
 
@@ -205,21 +312,54 @@ func convertToPod(o interface{}) (*apiv1.Pod, error) {
 }
 
 const (
-	podIPLabel                    = metaLabelPrefix + "pod_ip"
-	podContainerNameLabel         = metaLabelPrefix + "pod_container_name"
-	podContainerIDLabel           = metaLabelPrefix + "pod_container_id"
-	podContainerImageLabel        = metaLabelPrefix + "pod_container_image"
-	podContainerPortNameLabel     = metaLabelPrefix + "pod_container_port_name"
-	podContainerPortNumberLabel   = metaLabelPrefix + "pod_container_port_number"
-	podContainerPortProtocolLabel = metaLabelPrefix + "pod_container_port_protocol"
-	podContainerIsInit            = metaLabelPrefix + "pod_container_init"
-	podReadyLabel                 = metaLabelPrefix + "pod_ready"
-	podPhaseLabel                 = metaLabelPrefix + "pod_phase"
-	podNodeNameLabel              = metaLabelPrefix + "pod_node_name"
-	podHostIPLabel                = metaLabelPrefix + "pod_host_ip"
-	podUID                        = metaLabelPrefix + "pod_uid"
-	podControllerKind             = metaLabelPrefix + "pod_controller_kind"
-	podControllerName             = metaLabelPrefix + "pod_controller_name"
+	podIPLabel                              = metaLabelPrefix + "pod_ip"
+	podIPsLabel                             = metaLabelPrefix + "pod_ips"
+	podContainerNameLabel                   = metaLabelPrefix + "pod_container_name"
+	podContainerIDLabel                     = metaLabelPrefix + "pod_container_id"
+	podContainerImageLabel                  = metaLabelPrefix + "pod_container_image"
+	podContainerPortNameLabel               = metaLabelPrefix + "pod_container_port_name"
+	podContainerPortNumberLabel             = metaLabelPrefix + "pod_container_port_number"
+	podContainerPortProtocolLabel           = metaLabelPrefix + "pod_container_port_protocol"
+	podContainerIsInit                      = metaLabelPrefix + "pod_container_init"
+	podContainerResourceRequestsCPULabel    = metaLabelPrefix + "pod_container_resource_requests_cpu"
+	podContainerResourceRequestsMemoryLabel = metaLabelPrefix + "pod_container_resource_requests_memory"
+	podContainerResourceLimitsCPULabel      = metaLabelPrefix + "pod_container_resource_limits_cpu"
+	podContainerResourceLimitsMemoryLabel   = metaLabelPrefix + "pod_container_resource_limits_memory"
+	podReadyLabel                           = metaLabelPrefix + "pod_ready"
+	podPhaseLabel                           = metaLabelPrefix + "pod_phase"
+	podPhaseSinceLabel                      = metaLabelPrefix + "pod_phase_since"
+	podNodeNameLabel                        = metaLabelPrefix + "pod_node_name"
+	podSchedulerNameLabel                   = metaLabelPrefix + "pod_scheduler_name"
+	podPriorityLabel                        = metaLabelPrefix + "pod_priority"
+	podPriorityClassNameLabel               = metaLabelPrefix + "pod_priority_class_name"
+	podHostIPLabel                          = metaLabelPrefix + "pod_host_ip"
+	podQOSClassLabel                        = metaLabelPrefix + "pod_qos_class"
+	podUID                                  = metaLabelPrefix + "pod_uid"
+	podControllerKind                       = metaLabelPrefix + "pod_controller_kind"
+	podControllerName                       = metaLabelPrefix + "pod_controller_name"
+	podReplicaSetNameLabel                  = metaLabelPrefix + "pod_replicaset_name"
+	podTemplateHashLabel                    = metaLabelPrefix + "pod_template_hash"
+	podJobNameLabel                         = metaLabelPrefix + "pod_job_name"
+	podStatefulSetNameLabel                 = metaLabelPrefix + "pod_statefulset_name"
+	podIsLeaderLabel                        = metaLabelPrefix + "pod_is_leader"
+	podStatefulSetOrdinalLabel              = metaLabelPrefix + "pod_statefulset_ordinal"
+	podSpecHashLabel                        = metaLabelPrefix + "pod_spec_hash"
+	podContainerPortPrefix                  = metaLabelPrefix + "pod_container_port_"
+	podTolerationPrefix                     = metaLabelPrefix + "pod_toleration_"
+	podReadinessGatePrefix                  = metaLabelPrefix + "pod_readiness_gate_"
+	podHostnameLabel                        = metaLabelPrefix + "pod_hostname"
+	podSubdomainLabel                       = metaLabelPrefix + "pod_subdomain"
+	podContainerVolumePrefix                = metaLabelPrefix + "pod_container_volume_"
+	podPortAnnotationPortLabel              = metaLabelPrefix + "pod_port_annotation_port"
+	podPortAnnotationPathLabel              = metaLabelPrefix + "pod_port_annotation_path"
+	podPortAnnotationSchemeLabel            = metaLabelPrefix + "pod_port_annotation_scheme"
+
+	mountedValue = model.LabelValue("mounted")
+
+	// defaultLeaderAnnotation is the annotation consulted by the annotation
+	// leader election source when none is configured, the classic location
+	// of the client-go leader election record.
+	defaultLeaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
 )
 
 // GetControllerOf returns a pointer to a copy of the controllerRef if controllee has a controller
@@ -243,6 +383,77 @@ func podLabels(pod *apiv1.Pod) model.LabelSet {
 		podUID:           lv(string(pod.UID)),
 	}
 
+	// On dual-stack clusters the pod carries one IP per family; expose all of
+	// them so relabeling can pick a specific family.
+	if len(pod.Status.PodIPs) > 0 {
+		ips := make([]string, 0, len(pod.Status.PodIPs))
+		for _, ip := range pod.Status.PodIPs {
+			ips = append(ips, ip.IP)
+		}
+		ls[podIPsLabel] = lv(strings.Join(ips, ","))
+	}
+
+	// The QoS class may not be populated by the API server yet.
+	if pod.Status.QOSClass != "" {
+		ls[podQOSClassLabel] = lv(string(pod.Status.QOSClass))
+	}
+
+	if since := podPhaseTransitionTime(pod); !since.IsZero() {
+		ls[podPhaseSinceLabel] = lv(since.Format(time.RFC3339))
+	}
+
+	// The resolved priority and its class drive preemption decisions; the
+	// class name is left out for pods without one.
+	if pod.Spec.Priority != nil {
+		ls[podPriorityLabel] = lv(strconv.FormatInt(int64(*pod.Spec.Priority), 10))
+	}
+	if pod.Spec.PriorityClassName != "" {
+		ls[podPriorityClassNameLabel] = lv(pod.Spec.PriorityClassName)
+	}
+
+	// The scheduler name is left out when the API server did not default it.
+	if pod.Spec.SchedulerName != "" {
+		ls[podSchedulerNameLabel] = lv(pod.Spec.SchedulerName)
+	}
+
+	// The declared hostname and subdomain determine the pod's DNS name, which
+	// allows reconstructing its FQDN behind a headless service.
+	if pod.Spec.Hostname != "" {
+		ls[podHostnameLabel] = lv(pod.Spec.Hostname)
+	}
+	if pod.Spec.Subdomain != "" {
+		ls[podSubdomainLabel] = lv(pod.Spec.Subdomain)
+	}
+
+	// Tolerations without a key match everything and carry no useful identity,
+	// so they are skipped.
+	for _, tol := range pod.Spec.Tolerations {
+		if tol.Key == "" {
+			continue
+		}
+		v := tol.Value
+		if tol.Operator == apiv1.TolerationOpExists {
+			v = "true"
+		}
+		ln := strutil.SanitizeLabelName(podTolerationPrefix + tol.Key)
+		ls[model.LabelName(ln)] = lv(v)
+	}
+
+	// Each declared readiness gate is reported with the status of its
+	// matching condition, so targets can be kept only when all gates are
+	// satisfied. Conditions not reported yet show up as unknown.
+	for _, gate := range pod.Spec.ReadinessGates {
+		status := string(apiv1.ConditionUnknown)
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == gate.ConditionType {
+				status = string(cond.Status)
+				break
+			}
+		}
+		ln := strutil.SanitizeLabelName(podReadinessGatePrefix + string(gate.ConditionType))
+		ls[model.LabelName(ln)] = lv(strings.ToLower(status))
+	}
+
 	addObjectMetaLabels(ls, pod.ObjectMeta, RolePod)
 
 	createdBy := GetControllerOf(pod)
@@ -253,8 +464,103 @@ func podLabels(pod *apiv1.Pod) model.LabelSet {
 		if createdBy.Name != "" {
 			ls[podControllerName] = lv(createdBy.Name)
 		}
+		// Job-owned pods get a dedicated label so that batch workloads can be
+		// selected without matching on the generic controller kind, e.g. to
+		// drop completed job pods on phase Succeeded.
+		if createdBy.Kind == "Job" {
+			ls[podJobNameLabel] = lv(createdBy.Name)
+		}
+	}
+
+	return ls
+}
+
+// addStatefulSetLabels emits the owning StatefulSet's name and the replica
+// ordinal of pods owned by a StatefulSet, the latter parsed from the pod
+// name, giving a stable per-replica identity across reschedules. Other pods
+// are left untouched.
+func addStatefulSetLabels(ls model.LabelSet, pod *apiv1.Pod) {
+	owner := GetControllerOf(pod)
+	if owner == nil || owner.Kind != "StatefulSet" {
+		return
+	}
+	ls[podStatefulSetNameLabel] = lv(owner.Name)
+	i := strings.LastIndex(pod.Name, "-")
+	if i < 0 || i == len(pod.Name)-1 {
+		return
+	}
+	ordinal := pod.Name[i+1:]
+	if _, err := strconv.Atoi(ordinal); err != nil {
+		return
+	}
+	ls[podStatefulSetOrdinalLabel] = lv(ordinal)
+}
+
+// addOwnerWorkloadLabels overrides the controller kind and name labels with
+// the pod's owning workload, resolving the ReplicaSet indirection to the
+// Deployment through the ReplicaSet informer.
+func addOwnerWorkloadLabels(ls model.LabelSet, pod *apiv1.Pod, replicaSetInf cache.SharedInformer, logger *slog.Logger) {
+	owner := GetControllerOf(pod)
+	if owner == nil {
+		return
+	}
+
+	kind, name := owner.Kind, owner.Name
+	if kind == "ReplicaSet" {
+		// The direct ReplicaSet owner and the pod-template-hash identify the
+		// revision a pod belongs to, e.g. to separate canary from stable
+		// during a progressive rollout.
+		ls[podReplicaSetNameLabel] = lv(owner.Name)
+		if hash, ok := pod.Labels["pod-template-hash"]; ok {
+			ls[podTemplateHashLabel] = lv(hash)
+		}
+		obj, exists, err := replicaSetInf.GetStore().GetByKey(namespacedName(pod.Namespace, owner.Name))
+		if err != nil {
+			logger.Error("Error getting replicaset", "replicaset", owner.Name, "err", err)
+			return
+		}
+		if exists {
+			if rsOwner := GetControllerOf(obj.(*appsv1.ReplicaSet)); rsOwner != nil && rsOwner.Kind == "Deployment" {
+				kind, name = rsOwner.Kind, rsOwner.Name
+			}
+		}
 	}
 
+	ls[podControllerKind] = lv(kind)
+	ls[podControllerName] = lv(name)
+}
+
+// podSpecHashParts returns the normalized scrape-relevant parts of a pod used
+// for the spec hash label: the pod IP, container names and ports, and the
+// object labels. Status-only changes do not affect it.
+func podSpecHashParts(pod *apiv1.Pod) []string {
+	parts := []string{pod.Status.PodIP}
+	for _, c := range append(pod.Spec.Containers, pod.Spec.InitContainers...) {
+		parts = append(parts, c.Name)
+		for _, port := range c.Ports {
+			parts = append(parts, port.Name, strconv.FormatInt(int64(port.ContainerPort), 10), string(port.Protocol))
+		}
+	}
+	return append(parts, sortedObjectLabels(pod.Labels)...)
+}
+
+// containerResourceLabels returns the resource requests and limits of a
+// container as meta labels, normalized to base units (cores and bytes) so that
+// relabeling math is predictable. Resources that are not set are omitted.
+func containerResourceLabels(c *apiv1.Container) model.LabelSet {
+	ls := model.LabelSet{}
+	if q, ok := c.Resources.Requests[apiv1.ResourceCPU]; ok {
+		ls[podContainerResourceRequestsCPULabel] = lv(strconv.FormatFloat(q.AsApproximateFloat64(), 'f', -1, 64))
+	}
+	if q, ok := c.Resources.Requests[apiv1.ResourceMemory]; ok {
+		ls[podContainerResourceRequestsMemoryLabel] = lv(strconv.FormatInt(q.Value(), 10))
+	}
+	if q, ok := c.Resources.Limits[apiv1.ResourceCPU]; ok {
+		ls[podContainerResourceLimitsCPULabel] = lv(strconv.FormatFloat(q.AsApproximateFloat64(), 'f', -1, 64))
+	}
+	if q, ok := c.Resources.Limits[apiv1.ResourceMemory]; ok {
+		ls[podContainerResourceLimitsMemoryLabel] = lv(strconv.FormatInt(q.Value(), 10))
+	}
 	return ls
 }
 
@@ -276,22 +582,122 @@ func (p *Pod) findPodContainerID(statuses *[]apiv1.ContainerStatus, containerNam
 	return cStatus.ContainerID
 }
 
+// isLeader reports whether the pod holds a leadership according to the
+// configured leader election source: either a lease in the pod's namespace
+// names it as holder, or the pod carries the leader annotation.
+func (p *Pod) isLeader(pod *apiv1.Pod) bool {
+	if p.leaderAnnotation != "" {
+		if _, ok := pod.Annotations[p.leaderAnnotation]; ok {
+			return true
+		}
+	}
+	if !p.withLeaderLease {
+		return false
+	}
+	for _, o := range p.leaseInf.GetStore().List() {
+		lease, ok := o.(*coordinationv1.Lease)
+		if !ok || lease.Namespace != pod.Namespace {
+			continue
+		}
+		if holder, ok := leaseHolderPodName(lease); ok && holder == pod.Name {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Pod) buildPod(pod *apiv1.Pod) *targetgroup.Group {
 	tg := &targetgroup.Group{
 		Source: podSource(pod),
 	}
+	// A deletion timestamp marks the pod as terminating; the empty group
+	// drops its targets right away instead of waiting for the pod to go away.
+	if p.excludeTerminating && pod.DeletionTimestamp != nil {
+		return tg
+	}
 	// PodIP can be empty when a pod is starting or has been evicted.
 	if len(pod.Status.PodIP) == 0 {
+		if p.requirePodIP {
+			if _, ok := p.emitted[tg.Source]; ok {
+				// The pod was emitted before and lost its IP, e.g. on
+				// eviction: the empty group drops the stale targets.
+				return tg
+			}
+			// Hold the pod back entirely; the update event carrying the IP
+			// emits it, so downstream never sees the IP-less intermediate.
+			return nil
+		}
+		return tg
+	}
+	if p.withNodeMetadata && nodeExcludedByTaint(p.nodeInf, pod.Spec.NodeName, p.excludeNodeTaints) {
 		return tg
 	}
 
 	tg.Labels = podLabels(pod)
 	tg.Labels[namespaceLabel] = lv(pod.Namespace)
+	addStatefulSetLabels(tg.Labels, pod)
+	// The label is omitted entirely for non-leaders, so its mere presence
+	// can drive relabeling.
+	if p.isLeader(pod) {
+		tg.Labels[podIsLeaderLabel] = lv("true")
+	}
+	if p.withSpecHash {
+		tg.Labels[podSpecHashLabel] = specHash(podSpecHashParts(pod)...)
+	}
+	if p.withOwnerWorkload {
+		addOwnerWorkloadLabels(tg.Labels, pod, p.replicaSetInf, p.logger)
+	}
 	if p.withNodeMetadata {
 		tg.Labels = addNodeLabels(tg.Labels, p.nodeInf, p.logger, &pod.Spec.NodeName)
 	}
 	if p.withNamespaceMetadata {
-		tg.Labels = addNamespaceLabels(tg.Labels, p.namespaceInf, p.logger, pod.Namespace)
+		tg.Labels = addNamespaceLabels(tg.Labels, p.namespaceInf, p.logger, pod.Namespace, p.namespaceLabelPrefixes)
+	}
+
+	if p.portAnnotation != "" {
+		if targets, ok := p.podAnnotationTargets(pod); ok {
+			tg.Targets = targets
+			return tg
+		}
+	}
+
+	if p.defaultMetricsPort > 0 {
+		// A single target per pod on the conventional metrics port. The
+		// declared named container ports remain available for relabeling.
+		t := model.LabelSet{
+			model.AddressLabel: lv(net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(p.defaultMetricsPort))),
+		}
+		for _, c := range pod.Spec.Containers {
+			for _, port := range c.Ports {
+				if port.Name == "" {
+					continue
+				}
+				ln := strutil.SanitizeLabelName(podContainerPortPrefix + port.Name)
+				t[model.LabelName(ln)] = lv(strconv.FormatUint(uint64(port.ContainerPort), 10))
+			}
+		}
+		tg.Targets = append(tg.Targets, t)
+		return tg
+	}
+
+	if p.targetsPerPod {
+		// A single portless target per pod; the port is left to relabeling.
+		// The container meta labels describe the first container only, which
+		// is ambiguous for multi-container pods — the per-port targets remain
+		// the default for that reason.
+		t := model.LabelSet{
+			model.AddressLabel: lv(pod.Status.PodIP),
+		}
+		if len(pod.Spec.Containers) > 0 {
+			c := pod.Spec.Containers[0]
+			t[podContainerNameLabel] = lv(c.Name)
+			t[podContainerIDLabel] = lv(p.findPodContainerID(&pod.Status.ContainerStatuses, c.Name))
+			t[podContainerImageLabel] = lv(c.Image)
+			t[podContainerIsInit] = lv("false")
+			t = t.Merge(containerResourceLabels(&c))
+		}
+		tg.Targets = append(tg.Targets, t)
+		return tg
 	}
 
 	containers := append(pod.Spec.Containers, pod.Spec.InitContainers...)
@@ -303,6 +709,12 @@ func (p *Pod) buildPod(pod *apiv1.Pod) *targetgroup.Group {
 			cStatuses = &pod.Status.InitContainerStatuses
 		}
 		cID := p.findPodContainerID(cStatuses, c.Name)
+		containerLabels := containerResourceLabels(&c)
+		if p.withVolumeMounts {
+			for _, m := range c.VolumeMounts {
+				containerLabels[model.LabelName(podContainerVolumePrefix+strutil.SanitizeLabelName(m.Name))] = mountedValue
+			}
+		}
 
 		// If no ports are defined for the container, create an anonymous
 		// target per container.
@@ -315,11 +727,14 @@ func (p *Pod) buildPod(pod *apiv1.Pod) *targetgroup.Group {
 				podContainerIDLabel:    lv(cID),
 				podContainerImageLabel: lv(c.Image),
 				podContainerIsInit:     lv(strconv.FormatBool(isInit)),
-			})
+			}.Merge(containerLabels))
 			continue
 		}
 		// Otherwise create one target for each container/port combination.
 		for _, port := range c.Ports {
+			if p.onlyNamedPorts && port.Name == "" {
+				continue
+			}
 			ports := strconv.FormatUint(uint64(port.ContainerPort), 10)
 			addr := net.JoinHostPort(pod.Status.PodIP, ports)
 
@@ -332,13 +747,55 @@ func (p *Pod) buildPod(pod *apiv1.Pod) *targetgroup.Group {
 				podContainerPortNameLabel:     lv(port.Name),
 				podContainerPortProtocolLabel: lv(string(port.Protocol)),
 				podContainerIsInit:            lv(strconv.FormatBool(isInit)),
-			})
+			}.Merge(containerLabels))
 		}
 	}
 
 	return tg
 }
 
+// podAnnotationTargets builds one target per port declared in the pod's port
+// annotation. It returns false if the pod is not annotated or the annotation
+// value cannot be parsed, in which case the caller falls back to the regular
+// per-port targets.
+func (p *Pod) podAnnotationTargets(pod *apiv1.Pod) ([]model.LabelSet, bool) {
+	value, ok := pod.Annotations[p.portAnnotation]
+	if !ok {
+		return nil, false
+	}
+
+	var entries []struct {
+		Port   int    `json:"port"`
+		Path   string `json:"path"`
+		Scheme string `json:"scheme"`
+	}
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		p.logger.Error("Error parsing port annotation", "pod", podSource(pod), "annotation", p.portAnnotation, "err", err)
+		return nil, false
+	}
+
+	var targets []model.LabelSet
+	for _, e := range entries {
+		if e.Port <= 0 || e.Port > 65535 {
+			p.logger.Error("Invalid port in port annotation", "pod", podSource(pod), "annotation", p.portAnnotation, "port", e.Port)
+			continue
+		}
+		port := strconv.Itoa(e.Port)
+		t := model.LabelSet{
+			model.AddressLabel:         lv(net.JoinHostPort(pod.Status.PodIP, port)),
+			podPortAnnotationPortLabel: lv(port),
+		}
+		if e.Path != "" {
+			t[podPortAnnotationPathLabel] = lv(e.Path)
+		}
+		if e.Scheme != "" {
+			t[podPortAnnotationSchemeLabel] = lv(e.Scheme)
+		}
+		targets = append(targets, t)
+	}
+	return targets, true
+}
+
 func (p *Pod) enqueuePodsForNode(nodeName string) {
 	pods, err := p.podInf.GetIndexer().ByIndex(nodeIndex, nodeName)
 	if err != nil {
@@ -379,3 +836,41 @@ func podReady(pod *apiv1.Pod) model.LabelValue {
 	}
 	return lv(strings.ToLower(string(apiv1.ConditionUnknown)))
 }
+
+// podPhaseTransitionTime resolves when the pod entered its current phase, or
+// the zero time when that cannot be determined. It deliberately avoids the
+// Ready condition, which flaps without a phase change.
+func podPhaseTransitionTime(pod *apiv1.Pod) time.Time {
+	switch pod.Status.Phase {
+	case apiv1.PodPending:
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == apiv1.PodScheduled {
+				return cond.LastTransitionTime.Time
+			}
+		}
+	case apiv1.PodRunning:
+		if pod.Status.StartTime != nil {
+			return pod.Status.StartTime.Time
+		}
+	case apiv1.PodSucceeded, apiv1.PodFailed:
+		var latest time.Time
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Terminated != nil && cs.State.Terminated.FinishedAt.Time.After(latest) {
+				latest = cs.State.Terminated.FinishedAt.Time
+			}
+		}
+		return latest
+	}
+	return time.Time{}
+}
+
+// podReadyTransitionTime returns the time the pod's Ready condition last
+// changed, or the zero time if the condition is not present.
+func podReadyTransitionTime(pod *apiv1.Pod) time.Time {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == apiv1.PodReady {
+			return cond.LastTransitionTime.Time
+		}
+	}
+	return time.Time{}
+}
@@ -17,11 +17,20 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/model"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/prometheus/prometheus/discovery/targetgroup"
 )
@@ -229,6 +238,7 @@ func expectedPodTargetGroupsWithNodeMeta(ns, nodeName string, nodeLabels map[str
 	result := expectedPodTargetGroups(ns)
 	for _, tg := range result {
 		tg.Labels["__meta_kubernetes_node_name"] = lv(nodeName)
+		tg.Labels["__meta_kubernetes_node_unschedulable"] = lv("false")
 		for k, v := range nodeLabels {
 			tg.Labels[model.LabelName("__meta_kubernetes_node_label_"+k)] = lv(v)
 			tg.Labels[model.LabelName("__meta_kubernetes_node_labelpresent_"+k)] = lv("true")
@@ -330,13 +340,804 @@ func TestPodDiscoveryInitContainer(t *testing.T) {
 	}.Run(t)
 }
 
+// Native sidecars are init containers with restartPolicy Always; they keep
+// running alongside the regular containers and may expose metrics, so their
+// declared ports produce regular targets carrying the init label.
+func TestPodDiscoveryNativeSidecarContainer(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	ns := "default"
+	key := fmt.Sprintf("pod/%s/testpod", ns)
+	expected := expectedPodTargetGroups(ns)
+	expected[key].Targets = append(expected[key].Targets, model.LabelSet{
+		"__address__":                                   "1.2.3.4:9902",
+		"__meta_kubernetes_pod_container_name":          "sidecar",
+		"__meta_kubernetes_pod_container_image":         "sidecar:latest",
+		"__meta_kubernetes_pod_container_port_name":     "sidecarport",
+		"__meta_kubernetes_pod_container_port_number":   "9902",
+		"__meta_kubernetes_pod_container_port_protocol": "TCP",
+		"__meta_kubernetes_pod_container_init":          "true",
+		"__meta_kubernetes_pod_container_id":            "containerd://7a8b9c0d1e2f",
+	})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods(ns)
+			always := v1.ContainerRestartPolicyAlways
+			obj.Spec.InitContainers = []v1.Container{
+				{
+					Name:          "sidecar",
+					Image:         "sidecar:latest",
+					RestartPolicy: &always,
+					Ports: []v1.ContainerPort{
+						{
+							Name:          "sidecarport",
+							Protocol:      v1.ProtocolTCP,
+							ContainerPort: int32(9902),
+						},
+					},
+				},
+			}
+			obj.Status.InitContainerStatuses = []v1.ContainerStatus{
+				{
+					Name:        "sidecar",
+					ContainerID: "containerd://7a8b9c0d1e2f",
+				},
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryContainerResources(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		for i := range tg.Targets {
+			tg.Targets[i]["__meta_kubernetes_pod_container_resource_requests_cpu"] = "0.5"
+			tg.Targets[i]["__meta_kubernetes_pod_container_resource_requests_memory"] = "134217728"
+			tg.Targets[i]["__meta_kubernetes_pod_container_resource_limits_cpu"] = "1"
+			tg.Targets[i]["__meta_kubernetes_pod_container_resource_limits_memory"] = "268435456"
+		}
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			obj.Spec.Containers[0].Resources = v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("500m"),
+					v1.ResourceMemory: resource.MustParse("128Mi"),
+				},
+				Limits: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1"),
+					v1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryVolumeMounts(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.includeVolumeMounts = true
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		for i := range tg.Targets {
+			tg.Targets[i]["__meta_kubernetes_pod_container_volume_config_volume"] = "mounted"
+		}
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			obj.Spec.Containers[0].VolumeMounts = []v1.VolumeMount{
+				{
+					Name:      "config-volume",
+					MountPath: "/etc/config",
+				},
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryQOSClass(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_qos_class"] = "Burstable"
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			obj.Status.QOSClass = v1.PodQOSBurstable
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoverySpecHash(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.includeSpecHash = true
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_spec_hash"] = specHash(podSpecHashParts(makePods("default"))...)
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryWithOwnerWorkload(t *testing.T) {
+	t.Parallel()
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testdeployment-5d4b8c7f9",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind:       "Deployment",
+					Name:       "testdeployment",
+					Controller: makeOptionalBool(true),
+				},
+			},
+		},
+	}
+	n, c := makeDiscoveryWithMetadata(RolePod, NamespaceDiscovery{}, AttachMetadataConfig{OwnerWorkload: true}, replicaSet)
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_controller_kind"] = "Deployment"
+		tg.Labels["__meta_kubernetes_pod_controller_name"] = "testdeployment"
+		tg.Labels["__meta_kubernetes_pod_replicaset_name"] = "testdeployment-5d4b8c7f9"
+		tg.Labels["__meta_kubernetes_pod_template_hash"] = "5d4b8c7f9"
+		tg.Labels["__meta_kubernetes_pod_label_pod_template_hash"] = "5d4b8c7f9"
+		tg.Labels["__meta_kubernetes_pod_labelpresent_pod_template_hash"] = "true"
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			obj.Labels = map[string]string{"pod-template-hash": "5d4b8c7f9"}
+			obj.OwnerReferences = []metav1.OwnerReference{
+				{
+					Kind:       "ReplicaSet",
+					Name:       replicaSet.Name,
+					Controller: makeOptionalBool(true),
+				},
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
 func TestPodDiscoveryAdd(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
 
 	k8sDiscoveryTest{
 		discovery: n,
-		afterStart: func() {
+		afterStart: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expectedPodTargetGroups("default"),
+	}.Run(t)
+}
+
+func TestPodDiscoveryDelete(t *testing.T) {
+	t.Parallel()
+	obj := makePods("default")
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{}, obj)
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Delete(context.Background(), obj.Name, metav1.DeleteOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"pod/default/testpod": {
+				Source: "pod/default/testpod",
+			},
+		},
+	}.Run(t)
+}
+
+func TestPodDiscoveryLeaderLease(t *testing.T) {
+	t.Parallel()
+	obj := makePods("default")
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{}, obj)
+	n.leaderElection = &LeaderElectionConfig{Source: "lease"}
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testcontroller",
+			Namespace: "default",
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity: strptr("testpod_1234-abcd"),
+		},
+	}
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_is_leader"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			c.CoordinationV1().Leases(lease.Namespace).Create(context.Background(), lease, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryLeaderAnnotation(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.leaderElection = &LeaderElectionConfig{Source: "annotation"}
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_is_leader"] = "true"
+		tg.Labels["__meta_kubernetes_pod_annotation_control_plane_alpha_kubernetes_io_leader"] = "{}"
+		tg.Labels["__meta_kubernetes_pod_annotationpresent_control_plane_alpha_kubernetes_io_leader"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Annotations = map[string]string{defaultLeaderAnnotation: "{}"}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryExcludeTerminating(t *testing.T) {
+	t.Parallel()
+	obj := makePods("default")
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{}, obj)
+	n.excludeTerminating = true
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			deleted := metav1.Now()
+			obj.DeletionTimestamp = &deleted
+			c.CoreV1().Pods(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"pod/default/testpod": {
+				Source: "pod/default/testpod",
+			},
+		},
+	}.Run(t)
+}
+
+func TestPodDiscoveryUpdate(t *testing.T) {
+	t.Parallel()
+	obj := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testpod",
+			Namespace: "default",
+			UID:       "xyz321",
+		},
+		Spec: v1.PodSpec{
+			NodeName: "testnode",
+			Containers: []v1.Container{
+				{
+					Name:  "testcontainer",
+					Image: "testcontainer:latest",
+					Ports: []v1.ContainerPort{
+						{
+							Name:          "testport",
+							Protocol:      v1.ProtocolTCP,
+							ContainerPort: int32(9000),
+						},
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			PodIP:  "1.2.3.4",
+			HostIP: "2.3.4.5",
+		},
+	}
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{}, obj)
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes:      expectedPodTargetGroups("default"),
+	}.Run(t)
+}
+
+func TestPodDiscoveryUpdateEmptyPodIP(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	initialPod := makePods("default")
+
+	updatedPod := makePods("default")
+	updatedPod.Status.PodIP = ""
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			c.CoreV1().Pods(initialPod.Namespace).Create(context.Background(), initialPod, metav1.CreateOptions{})
+		},
+		afterStart: func() {
+			c.CoreV1().Pods(updatedPod.Namespace).Update(context.Background(), updatedPod, metav1.UpdateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"pod/default/testpod": {
+				Source: "pod/default/testpod",
+			},
+		},
+	}.Run(t)
+}
+
+func TestPodDiscoveryNamespaces(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{Names: []string{"ns1", "ns2"}})
+
+	expected := expectedPodTargetGroups("ns1")
+	for k, v := range expectedPodTargetGroups("ns2") {
+		expected[k] = v
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			for _, ns := range []string{"ns1", "ns2"} {
+				pod := makePods("default")
+				pod.Namespace = ns
+				c.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			}
+		},
+		expectedMaxItems: 2,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryNamespaceSelectors(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{Names: []string{"ns1", "ns2"}})
+	n.namespacedSelectors = map[Role]map[string]resourceSelector{
+		RolePod: {"ns1": {label: "team=a"}},
+	}
+
+	expected := expectedPodTargetGroups("ns1")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_label_team"] = "a"
+		tg.Labels["__meta_kubernetes_pod_labelpresent_team"] = "true"
+	}
+	for k, v := range expectedPodTargetGroups("ns2") {
+		expected[k] = v
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			selected := makePods("ns1")
+			selected.Labels = map[string]string{"team": "a"}
+			c.CoreV1().Pods(selected.Namespace).Create(context.Background(), selected, metav1.CreateOptions{})
+
+			// Not matching the ns1 selector, must not be discovered.
+			other := makePods("ns1")
+			other.Name = "otherpod"
+			c.CoreV1().Pods(other.Namespace).Create(context.Background(), other, metav1.CreateOptions{})
+
+			// ns2 is not covered by the selector, so the pod is discovered
+			// without restrictions.
+			pod := makePods("ns2")
+			c.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryDualStackIPs(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_ips"] = "1.2.3.4,2001:db8::1"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Status.PodIPs = []v1.PodIP{
+				{IP: "1.2.3.4"},
+				{IP: "2001:db8::1"},
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryExcludeNodeTaints(t *testing.T) {
+	t.Parallel()
+	nodes := makeNode("testnode", "1.2.3.4", "", map[string]string{}, map[string]string{})
+	nodes.Spec.Taints = []v1.Taint{
+		{Key: "nvidia.com/gpu", Value: "present", Effect: v1.TaintEffectNoSchedule},
+	}
+	n, c := makeDiscoveryWithMetadata(RolePod, NamespaceDiscovery{}, AttachMetadataConfig{Node: true}, nodes)
+	n.excludeNodeTaints = []string{"nvidia.com/gpu"}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			pods := makePods("default")
+			c.CoreV1().Pods(pods.Namespace).Create(context.Background(), pods, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"pod/default/testpod": {
+				Source: "pod/default/testpod",
+			},
+		},
+	}.Run(t)
+}
+
+func TestPodDiscoveryTrimObjectCache(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.trimObjectCache = true
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_annotation_test_annotation"] = "testannotationvalue"
+		tg.Labels["__meta_kubernetes_pod_annotationpresent_test_annotation"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Annotations = map[string]string{
+				"test-annotation":           "testannotationvalue",
+				lastAppliedConfigAnnotation: "{}",
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryHostnameSubdomain(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_hostname"] = "web-0"
+		tg.Labels["__meta_kubernetes_pod_subdomain"] = "web"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Spec.Hostname = "web-0"
+			obj.Spec.Subdomain = "web"
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoverySelectorFromConfigMap(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.selectorFromConfigMap = &SelectorFromConfigMapConfig{
+		Namespace: "default",
+		Name:      "scrape-selector",
+		Key:       "selector",
+	}
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "scrape-selector", Namespace: "default"},
+		Data:       map[string]string{"selector": "app=foo"},
+	}
+	matching := makePods("default")
+	matching.Labels = map[string]string{"app": "foo"}
+	other := makePods("default")
+	other.Name = "otherpod"
+	other.Labels = map[string]string{"app": "bar"}
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_label_app"] = "foo"
+		tg.Labels["__meta_kubernetes_pod_labelpresent_app"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			c.CoreV1().ConfigMaps(cm.Namespace).Create(context.Background(), cm, metav1.CreateOptions{})
+			for _, pod := range []*v1.Pod{matching, other} {
+				c.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			}
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoverySelectorFromConfigMapNarrowed(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.selectorFromConfigMap = &SelectorFromConfigMapConfig{
+		Namespace: "default",
+		Name:      "scrape-selector",
+		Key:       "selector",
+	}
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "scrape-selector", Namespace: "default"},
+		Data:       map[string]string{"selector": "app=foo"},
+	}
+	matching := makePods("default")
+	matching.Labels = map[string]string{"app": "foo"}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			c.CoreV1().ConfigMaps(cm.Namespace).Create(context.Background(), cm, metav1.CreateOptions{})
+			c.CoreV1().Pods(matching.Namespace).Create(context.Background(), matching, metav1.CreateOptions{})
+		},
+		afterStart: func() {
+			// Narrowing the selector restarts discovery; the de-selected
+			// pod's group must be cleared, not left stale.
+			narrowed := cm.DeepCopy()
+			narrowed.Data["selector"] = "app=bar"
+			c.CoreV1().ConfigMaps(narrowed.Namespace).Update(context.Background(), narrowed, metav1.UpdateOptions{})
+		},
+		// Both the initial and the post-restart batch cover the same source,
+		// so rely on the read timeout to merge them.
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"pod/default/testpod": {
+				Source: "pod/default/testpod",
+			},
+		},
+	}.Run(t)
+}
+
+func TestPodDiscoverySelectorFromCustomResource(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.selectorFromCustomResource = &SelectorFromCustomResourceConfig{
+		Group:    "monitoring.example.com",
+		Version:  "v1",
+		Resource: "podmonitors",
+	}
+	c.(*fake.Clientset).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "monitoring.example.com/v1",
+			APIResources: []metav1.APIResource{{Name: "podmonitors", Kind: "PodMonitor"}},
+		},
+	}
+	pm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.example.com/v1",
+		"kind":       "PodMonitor",
+		"metadata":   map[string]interface{}{"name": "testmonitor", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "foo"},
+			},
+		},
+	}}
+	n.dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{n.selectorFromCustomResource.groupVersionResource(): "PodMonitorList"},
+		pm,
+	)
+
+	matching := makePods("default")
+	matching.Labels = map[string]string{"app": "foo"}
+	other := makePods("default")
+	other.Name = "otherpod"
+	other.Labels = map[string]string{"app": "bar"}
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_label_app"] = "foo"
+		tg.Labels["__meta_kubernetes_pod_labelpresent_app"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			for _, pod := range []*v1.Pod{matching, other} {
+				c.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			}
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryDefaultMetricsPort(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.defaultMetricsPort = 9090
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Targets = []model.LabelSet{
+			{
+				"__address__": "1.2.3.4:9090",
+				"__meta_kubernetes_pod_container_port_testport": "9000",
+			},
+		}
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryTargetsPerPod(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.podTargetsPerPod = true
+
+	// The multi-port, multi-container pod collapses to a single portless
+	// target carrying the first container's meta labels.
+	expected := map[string]*targetgroup.Group{
+		"pod/default/testpod": {
+			Targets: []model.LabelSet{
+				{
+					"__address__":                           "1.2.3.4",
+					"__meta_kubernetes_pod_container_name":  "testcontainer0",
+					"__meta_kubernetes_pod_container_image": "testcontainer0:latest",
+					"__meta_kubernetes_pod_container_init":  "false",
+					"__meta_kubernetes_pod_container_id":    "docker://a1b2c3d4e5f6",
+				},
+			},
+			Labels: model.LabelSet{
+				"__meta_kubernetes_pod_name":                              "testpod",
+				"__meta_kubernetes_namespace":                             "default",
+				"__meta_kubernetes_pod_label_test_label":                  "testvalue",
+				"__meta_kubernetes_pod_labelpresent_test_label":           "true",
+				"__meta_kubernetes_pod_annotation_test_annotation":        "testannotationvalue",
+				"__meta_kubernetes_pod_annotationpresent_test_annotation": "true",
+				"__meta_kubernetes_pod_node_name":                         "testnode",
+				"__meta_kubernetes_pod_ip":                                "1.2.3.4",
+				"__meta_kubernetes_pod_host_ip":                           "2.3.4.5",
+				"__meta_kubernetes_pod_ready":                             "true",
+				"__meta_kubernetes_pod_phase":                             "Running",
+				"__meta_kubernetes_pod_uid":                               "abc123",
+				"__meta_kubernetes_pod_controller_kind":                   "testcontrollerkind",
+				"__meta_kubernetes_pod_controller_name":                   "testcontrollername",
+			},
+			Source: "pod/default/testpod",
+		},
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makeMultiPortPods()
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryPortAnnotation(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.portAnnotation = "prometheus.io/ports"
+
+	// The annotated pod emits one target per annotation entry instead of the
+	// per-port targets; the declared container ports are ignored.
+	expected := map[string]*targetgroup.Group{
+		"pod/default/testpod": {
+			Targets: []model.LabelSet{
+				{
+					"__address__": "1.2.3.4:9090",
+					"__meta_kubernetes_pod_port_annotation_port": "9090",
+					"__meta_kubernetes_pod_port_annotation_path": "/metrics",
+				},
+				{
+					"__address__": "1.2.3.4:8080",
+					"__meta_kubernetes_pod_port_annotation_port":   "8080",
+					"__meta_kubernetes_pod_port_annotation_path":   "/-/metrics",
+					"__meta_kubernetes_pod_port_annotation_scheme": "https",
+				},
+			},
+			Labels: model.LabelSet{
+				"__meta_kubernetes_pod_name":                                  "testpod",
+				"__meta_kubernetes_namespace":                                 "default",
+				"__meta_kubernetes_pod_annotation_prometheus_io_ports":        `[{"port":9090,"path":"/metrics"},{"port":8080,"scheme":"https","path":"/-/metrics"}]`,
+				"__meta_kubernetes_pod_annotationpresent_prometheus_io_ports": "true",
+				"__meta_kubernetes_pod_node_name":                             "testnode",
+				"__meta_kubernetes_pod_ip":                                    "1.2.3.4",
+				"__meta_kubernetes_pod_host_ip":                               "2.3.4.5",
+				"__meta_kubernetes_pod_ready":                                 "true",
+				"__meta_kubernetes_pod_phase":                                 "Running",
+				"__meta_kubernetes_pod_uid":                                   "abc123",
+			},
+			Source: "pod/default/testpod",
+		},
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Annotations = map[string]string{
+				"prometheus.io/ports": `[{"port":9090,"path":"/metrics"},{"port":8080,"scheme":"https","path":"/-/metrics"}]`,
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryPortAnnotationUnannotated(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.portAnnotation = "prometheus.io/ports"
+
+	// Pods without the annotation keep the regular per-port targets.
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
 			obj := makePods("default")
 			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		},
@@ -345,17 +1146,51 @@ func TestPodDiscoveryAdd(t *testing.T) {
 	}.Run(t)
 }
 
-func TestPodDiscoveryDelete(t *testing.T) {
+func TestPodDiscoveryRequirePodIP(t *testing.T) {
 	t.Parallel()
-	obj := makePods("default")
-	n, c := makeDiscovery(RolePod, NamespaceDiscovery{}, obj)
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.requirePodIP = true
 
+	// A pending pod without an IP is held back entirely; the status update
+	// carrying the IP produces exactly one add.
 	k8sDiscoveryTest{
 		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Status.PodIP = ""
+			obj.Status.Phase = "Pending"
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
 		afterStart: func() {
 			obj := makePods("default")
-			c.CoreV1().Pods(obj.Namespace).Delete(context.Background(), obj.Name, metav1.DeleteOptions{})
+			c.CoreV1().Pods(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expectedPodTargetGroups("default"),
+	}.Run(t)
+}
+
+func TestPodDiscoveryRequirePodIPLost(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.requirePodIP = true
+
+	// A pod that was already emitted and loses its IP again, e.g. on
+	// eviction, has its stale targets cleared instead of being held back.
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		afterStart: func() {
+			obj := makePods("default")
+			obj.Status.PodIP = ""
+			obj.Status.Phase = "Failed"
+			c.CoreV1().Pods(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
 		},
+		// Both batches cover the same source, so rely on the read timeout to
+		// merge them.
 		expectedMaxItems: 2,
 		expectedRes: map[string]*targetgroup.Group{
 			"pod/default/testpod": {
@@ -365,81 +1200,195 @@ func TestPodDiscoveryDelete(t *testing.T) {
 	}.Run(t)
 }
 
-func TestPodDiscoveryUpdate(t *testing.T) {
+func TestPodDiscoveryPhaseSince(t *testing.T) {
 	t.Parallel()
-	obj := &v1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "testpod",
-			Namespace: "default",
-			UID:       "xyz321",
-		},
-		Spec: v1.PodSpec{
-			NodeName: "testnode",
-			Containers: []v1.Container{
-				{
-					Name:  "testcontainer",
-					Image: "testcontainer:latest",
-					Ports: []v1.ContainerPort{
-						{
-							Name:          "testport",
-							Protocol:      v1.ProtocolTCP,
-							ContainerPort: int32(9000),
-						},
-					},
-				},
-			},
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	started := metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_phase_since"] = "2024-01-02T03:04:05Z"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Status.StartTime = &started
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		},
-		Status: v1.PodStatus{
-			PodIP:  "1.2.3.4",
-			HostIP: "2.3.4.5",
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryReadinessGates(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_readiness_gate_example_com_gate"] = "true"
+		tg.Labels["__meta_kubernetes_pod_readiness_gate_example_com_pending"] = "unknown"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Spec.ReadinessGates = []v1.PodReadinessGate{
+				{ConditionType: "example.com/gate"},
+				{ConditionType: "example.com/pending"},
+			}
+			// Only the first gate's condition has been reported.
+			obj.Status.Conditions = append(obj.Status.Conditions, v1.PodCondition{
+				Type:   "example.com/gate",
+				Status: v1.ConditionTrue,
+			})
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoverySchedulerName(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_scheduler_name"] = "custom-scheduler"
 	}
-	n, c := makeDiscovery(RolePod, NamespaceDiscovery{}, obj)
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Spec.SchedulerName = "custom-scheduler"
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryPriority(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
 
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_priority"] = "1000000"
+		tg.Labels["__meta_kubernetes_pod_priority_class_name"] = "high-priority"
+	}
 	k8sDiscoveryTest{
 		discovery: n,
-		afterStart: func() {
+		beforeRun: func() {
 			obj := makePods("default")
-			c.CoreV1().Pods(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
+			priority := int32(1000000)
+			obj.Spec.Priority = &priority
+			obj.Spec.PriorityClassName = "high-priority"
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		},
-		expectedMaxItems: 2,
-		expectedRes:      expectedPodTargetGroups("default"),
+		expectedMaxItems: 1,
+		expectedRes:      expected,
 	}.Run(t)
 }
 
-func TestPodDiscoveryUpdateEmptyPodIP(t *testing.T) {
+func TestPodDiscoveryJobName(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
-	initialPod := makePods("default")
 
-	updatedPod := makePods("default")
-	updatedPod.Status.PodIP = ""
+	isController := true
+	makeJobPod := func() *v1.Pod {
+		obj := makePods("default")
+		obj.OwnerReferences = []metav1.OwnerReference{
+			{Kind: "Job", Name: "testjob", Controller: &isController},
+		}
+		return obj
+	}
 
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_controller_kind"] = "Job"
+		tg.Labels["__meta_kubernetes_pod_controller_name"] = "testjob"
+		tg.Labels["__meta_kubernetes_pod_job_name"] = "testjob"
+		// The update below moves the pod to Succeeded, so the final target
+		// group reflects the completed phase and can be dropped on it.
+		tg.Labels["__meta_kubernetes_pod_phase"] = "Succeeded"
+	}
 	k8sDiscoveryTest{
 		discovery: n,
 		beforeRun: func() {
-			c.CoreV1().Pods(initialPod.Namespace).Create(context.Background(), initialPod, metav1.CreateOptions{})
+			obj := makeJobPod()
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		},
 		afterStart: func() {
-			c.CoreV1().Pods(updatedPod.Namespace).Update(context.Background(), updatedPod, metav1.UpdateOptions{})
+			obj := makeJobPod()
+			obj.Status.Phase = "Succeeded"
+			c.CoreV1().Pods(obj.Namespace).Update(context.Background(), obj, metav1.UpdateOptions{})
 		},
 		expectedMaxItems: 2,
-		expectedRes: map[string]*targetgroup.Group{
-			"pod/default/testpod": {
-				Source: "pod/default/testpod",
-			},
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryTolerations(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	expected := expectedPodTargetGroups("default")
+	for _, tg := range expected {
+		tg.Labels["__meta_kubernetes_pod_toleration_dedicated"] = "monitoring"
+		tg.Labels["__meta_kubernetes_pod_toleration_node_kubernetes_io_not_ready"] = "true"
+	}
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Spec.Tolerations = []v1.Toleration{
+				{
+					Key:      "dedicated",
+					Operator: v1.TolerationOpEqual,
+					Value:    "monitoring",
+					Effect:   v1.TaintEffectNoSchedule,
+				},
+				{
+					Key:      "node.kubernetes.io/not-ready",
+					Operator: v1.TolerationOpExists,
+					Effect:   v1.TaintEffectNoExecute,
+				},
+			}
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes:      expected,
+	}.Run(t)
+}
+
+func TestPodDiscoveryOnlyNamedPorts(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+	n.onlyNamedPorts = true
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			obj := makePods("default")
+			obj.Spec.Containers[0].Ports = append(obj.Spec.Containers[0].Ports, v1.ContainerPort{
+				Protocol:      v1.ProtocolTCP,
+				ContainerPort: int32(9001),
+			})
+			c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		},
+		expectedMaxItems: 1,
+		expectedRes:      expectedPodTargetGroups("default"),
 	}.Run(t)
 }
 
-func TestPodDiscoveryNamespaces(t *testing.T) {
+func TestPodDiscoveryExcludeNamespaces(t *testing.T) {
 	t.Parallel()
-	n, c := makeDiscovery(RolePod, NamespaceDiscovery{Names: []string{"ns1", "ns2"}})
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{ExcludeNames: []string{"ns2"}})
 
 	expected := expectedPodTargetGroups("ns1")
-	for k, v := range expectedPodTargetGroups("ns2") {
-		expected[k] = v
-	}
 	k8sDiscoveryTest{
 		discovery: n,
 		beforeRun: func() {
@@ -449,7 +1398,7 @@ func TestPodDiscoveryNamespaces(t *testing.T) {
 				c.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
 			}
 		},
-		expectedMaxItems: 2,
+		expectedMaxItems: 1,
 		expectedRes:      expected,
 	}.Run(t)
 }
@@ -567,6 +1516,55 @@ func TestPodDiscoveryWithNamespaceMetadata(t *testing.T) {
 	}.Run(t)
 }
 
+func TestPodDiscoveryWithNamespaceMetadataLabelPrefixes(t *testing.T) {
+	t.Parallel()
+
+	ns := "test-ns"
+	nsLabels := map[string]string{"team": "observability", "team-contact": "oncall", "cost-center": "42"}
+	nsAnnotations := map[string]string{"maintainer": "devops"}
+
+	// Only namespace labels matching one of the prefixes are attached;
+	// annotations are unaffected.
+	n, _ := makeDiscoveryWithMetadata(RolePod, NamespaceDiscovery{}, AttachMetadataConfig{Namespace: true, NamespaceLabelPrefixes: []string{"team"}}, makeNamespace(ns, nsLabels, nsAnnotations), makePods(ns))
+	k8sDiscoveryTest{
+		discovery:        n,
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			fmt.Sprintf("pod/%s/testpod", ns): {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                                   "1.2.3.4:9000",
+						"__meta_kubernetes_pod_container_image":         "testcontainer:latest",
+						"__meta_kubernetes_pod_container_name":          "testcontainer",
+						"__meta_kubernetes_pod_container_port_name":     "testport",
+						"__meta_kubernetes_pod_container_port_number":   "9000",
+						"__meta_kubernetes_pod_container_port_protocol": "TCP",
+						"__meta_kubernetes_pod_container_init":          "false",
+						"__meta_kubernetes_pod_container_id":            "docker://a1b2c3d4e5f6",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_namespace":                              model.LabelValue(ns),
+					"__meta_kubernetes_namespace_annotation_maintainer":        "devops",
+					"__meta_kubernetes_namespace_annotationpresent_maintainer": "true",
+					"__meta_kubernetes_namespace_label_team":                   "observability",
+					"__meta_kubernetes_namespace_labelpresent_team":            "true",
+					"__meta_kubernetes_namespace_label_team_contact":           "oncall",
+					"__meta_kubernetes_namespace_labelpresent_team_contact":    "true",
+					"__meta_kubernetes_pod_name":                               "testpod",
+					"__meta_kubernetes_pod_ip":                                 "1.2.3.4",
+					"__meta_kubernetes_pod_ready":                              "true",
+					"__meta_kubernetes_pod_phase":                              "Running",
+					"__meta_kubernetes_pod_node_name":                          "testnode",
+					"__meta_kubernetes_pod_host_ip":                            "2.3.4.5",
+					"__meta_kubernetes_pod_uid":                                "abc123",
+				},
+				Source: fmt.Sprintf("pod/%s/testpod", ns),
+			},
+		},
+	}.Run(t)
+}
+
 func TestPodDiscoveryWithUpdatedNamespaceMetadata(t *testing.T) {
 	t.Parallel()
 
@@ -628,3 +1626,46 @@ func TestPodDiscoveryWithUpdatedNamespaceMetadata(t *testing.T) {
 		},
 	}.Run(t)
 }
+
+func TestPodDiscoveryStatefulSetOrdinal(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	makeStatefulSetPod := func(name string) *v1.Pod {
+		obj := makePods("default")
+		obj.Name = name
+		obj.OwnerReferences = []metav1.OwnerReference{
+			{
+				Kind:       "StatefulSet",
+				Name:       "mydb",
+				Controller: makeOptionalBool(true),
+			},
+		}
+		return obj
+	}
+
+	expected := map[string]*targetgroup.Group{}
+	for _, name := range []string{"mydb-0", "mydb-12"} {
+		for _, tg := range expectedPodTargetGroups("default") {
+			tg.Source = "pod/default/" + name
+			tg.Labels["__meta_kubernetes_pod_name"] = model.LabelValue(name)
+			tg.Labels["__meta_kubernetes_pod_controller_kind"] = "StatefulSet"
+			tg.Labels["__meta_kubernetes_pod_controller_name"] = "mydb"
+			tg.Labels["__meta_kubernetes_pod_statefulset_name"] = "mydb"
+			tg.Labels["__meta_kubernetes_pod_statefulset_ordinal"] = model.LabelValue(name[len("mydb-"):])
+			expected[tg.Source] = tg
+		}
+	}
+
+	k8sDiscoveryTest{
+		discovery: n,
+		beforeRun: func() {
+			for _, name := range []string{"mydb-0", "mydb-12"} {
+				obj := makeStatefulSetPod(name)
+				c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+			}
+		},
+		expectedMaxItems: 2,
+		expectedRes:      expected,
+	}.Run(t)
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// previewSettleDelay is how long preview waits after the initial cache sync
+// without new target groups arriving before taking the snapshot, giving the
+// discoverers time to drain their work queues.
+const previewSettleDelay = time.Second
+
+// Preview produces the initial target snapshot a configuration would emit
+// and returns it, without keeping the discovery running. It goes through the
+// regular target-building code paths, so the result matches what a running
+// Prometheus would discover, and tears everything down once the initial
+// snapshot has settled. It is meant for debugging selector and relabeling
+// issues, e.g. from a promtool subcommand.
+//
+// The context bounds the whole operation; when its deadline expires before
+// the caches have synced, the target groups received so far are returned
+// alongside the context's error.
+func Preview(ctx context.Context, conf *SDConfig) ([]*targetgroup.Group, error) {
+	reg := prometheus.NewRegistry()
+	metrics := conf.NewDiscovererMetrics(reg, discovery.NewRefreshMetrics(reg))
+	if err := metrics.Register(); err != nil {
+		return nil, err
+	}
+	defer metrics.Unregister()
+
+	d, err := New(nil, metrics, conf)
+	if err != nil {
+		return nil, err
+	}
+	return d.preview(ctx)
+}
+
+// preview runs the discovery until its initial snapshot has settled, then
+// stops it and returns the accumulated target groups sorted by source.
+func (d *Discovery) preview(ctx context.Context) ([]*targetgroup.Group, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan []*targetgroup.Group)
+	done := make(chan struct{})
+	go func() {
+		d.Run(runCtx, ch)
+		close(done)
+	}()
+
+	groups := map[string]*targetgroup.Group{}
+	// The timer only starts counting once the caches have synced; until then
+	// it is parked far in the future so a slow initial LIST is bounded by the
+	// caller's context alone.
+	settle := time.NewTimer(time.Hour)
+	defer settle.Stop()
+	syncDone := d.initialSyncDone
+
+	var err error
+collect:
+	for {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break collect
+		case <-syncDone:
+			// Nil out the closed channel so this case only fires once.
+			syncDone = nil
+			settle.Reset(previewSettleDelay)
+		case <-settle.C:
+			break collect
+		case tgs := <-ch:
+			for _, tg := range tgs {
+				if tg == nil {
+					continue
+				}
+				groups[tg.Source] = tg
+			}
+			if syncDone == nil {
+				settle.Reset(previewSettleDelay)
+			}
+		}
+	}
+
+	cancel()
+	<-done
+
+	res := make([]*targetgroup.Group, 0, len(groups))
+	for _, tg := range groups {
+		res = append(res, tg)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Source < res[j].Source })
+	return res, err
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPreviewSnapshot(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	obj := makePods("default")
+	_, err := c.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	groups, err := n.preview(ctx)
+	require.NoError(t, err)
+
+	expected := expectedPodTargetGroups("default")
+	require.Len(t, groups, len(expected))
+	for _, tg := range groups {
+		require.Equal(t, expected[tg.Source], tg)
+	}
+}
+
+func TestPreviewContextDeadline(t *testing.T) {
+	t.Parallel()
+	n, _ := makeDiscovery(RolePod, NamespaceDiscovery{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	groups, err := n.preview(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, groups)
+}
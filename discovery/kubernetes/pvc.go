@@ -0,0 +1,232 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// PersistentVolumeClaim implements discovery of Kubernetes PersistentVolumeClaims.
+type PersistentVolumeClaim struct {
+	logger                 *slog.Logger
+	informer               cache.SharedIndexInformer
+	store                  cache.Store
+	queue                  *workqueue.Type
+	namespaceInf           cache.SharedInformer
+	withNamespaceMetadata  bool
+	namespaceLabelPrefixes []string
+}
+
+// NewPersistentVolumeClaim returns a new pvc discovery.
+func NewPersistentVolumeClaim(l *slog.Logger, inf cache.SharedIndexInformer, namespace cache.SharedInformer, eventCount *prometheus.CounterVec, namespaceLabelPrefixes []string) *PersistentVolumeClaim {
+	if l == nil {
+		l = promslog.NewNopLogger()
+	}
+
+	pvcAddCount := eventCount.WithLabelValues(RolePVC.String(), MetricLabelRoleAdd)
+	pvcUpdateCount := eventCount.WithLabelValues(RolePVC.String(), MetricLabelRoleUpdate)
+	pvcDeleteCount := eventCount.WithLabelValues(RolePVC.String(), MetricLabelRoleDelete)
+
+	p := &PersistentVolumeClaim{
+		logger:                 l,
+		informer:               inf,
+		store:                  inf.GetStore(),
+		queue:                  workqueue.NewNamed(RolePVC.String()),
+		namespaceInf:           namespace,
+		withNamespaceMetadata:  namespace != nil,
+		namespaceLabelPrefixes: namespaceLabelPrefixes,
+	}
+
+	_, err := p.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(o interface{}) {
+			pvcAddCount.Inc()
+			p.enqueue(o)
+		},
+		DeleteFunc: func(o interface{}) {
+			pvcDeleteCount.Inc()
+			p.enqueue(o)
+		},
+		UpdateFunc: func(_, o interface{}) {
+			pvcUpdateCount.Inc()
+			p.enqueue(o)
+		},
+	})
+	if err != nil {
+		l.Error("Error adding persistentvolumeclaims event handler.", "err", err)
+	}
+
+	if p.withNamespaceMetadata {
+		_, err = p.namespaceInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, o interface{}) {
+				namespace := o.(*apiv1.Namespace)
+				p.enqueueNamespace(namespace.Name)
+			},
+			// Creation and deletion will trigger events for the change handlers of the resources within the namespace.
+			// No need to have additional handlers for them here.
+		})
+		if err != nil {
+			l.Error("Error adding namespaces event handler.", "err", err)
+		}
+	}
+
+	return p
+}
+
+func (p *PersistentVolumeClaim) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+
+	p.queue.Add(key)
+}
+
+func (p *PersistentVolumeClaim) enqueueNamespace(namespace string) {
+	pvcs, err := p.informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		p.logger.Error("Error getting persistentvolumeclaims in namespace", "namespace", namespace, "err", err)
+		return
+	}
+
+	for _, pvc := range pvcs {
+		p.enqueue(pvc)
+	}
+}
+
+// Run implements the Discoverer interface.
+func (p *PersistentVolumeClaim) Run(ctx context.Context, ch chan<- []*targetgroup.Group) {
+	defer p.queue.ShutDown()
+
+	cacheSyncs := []cache.InformerSynced{p.informer.HasSynced}
+	if p.withNamespaceMetadata {
+		cacheSyncs = append(cacheSyncs, p.namespaceInf.HasSynced)
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			p.logger.Error("pvc informer unable to sync cache")
+		}
+		return
+	}
+
+	go func() {
+		for p.process(ctx, ch) {
+		}
+	}()
+
+	// Block until the target provider is explicitly canceled.
+	<-ctx.Done()
+}
+
+func (p *PersistentVolumeClaim) process(ctx context.Context, ch chan<- []*targetgroup.Group) bool {
+	keyObj, quit := p.queue.Get()
+	if quit {
+		return false
+	}
+	defer p.queue.Done(keyObj)
+	key := keyObj.(string)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return true
+	}
+
+	o, exists, err := p.store.GetByKey(key)
+	if err != nil {
+		return true
+	}
+	if !exists {
+		send(ctx, ch, &targetgroup.Group{Source: pvcSourceFromNamespaceAndName(namespace, name)})
+		return true
+	}
+	pvc, err := convertToPersistentVolumeClaim(o)
+	if err != nil {
+		p.logger.Error("converting to PersistentVolumeClaim object failed", "err", err)
+		return true
+	}
+	send(ctx, ch, p.buildPVC(pvc))
+	return true
+}
+
+func convertToPersistentVolumeClaim(o interface{}) (*apiv1.PersistentVolumeClaim, error) {
+	pvc, ok := o.(*apiv1.PersistentVolumeClaim)
+	if ok {
+		return pvc, nil
+	}
+	return nil, fmt.Errorf("received unexpected object: %v", o)
+}
+
+func pvcSource(p *apiv1.PersistentVolumeClaim) string {
+	return pvcSourceFromNamespaceAndName(p.Namespace, p.Name)
+}
+
+func pvcSourceFromNamespaceAndName(namespace, name string) string {
+	return "pvc/" + namespace + "/" + name
+}
+
+const (
+	pvcStorageClassLabel = metaLabelPrefix + "pvc_storageclass"
+	pvcPhaseLabel        = metaLabelPrefix + "pvc_phase"
+	pvcVolumeNameLabel   = metaLabelPrefix + "pvc_volume_name"
+)
+
+func pvcLabels(p *apiv1.PersistentVolumeClaim) model.LabelSet {
+	ls := make(model.LabelSet)
+	ls[namespaceLabel] = lv(p.Namespace)
+	addObjectMetaLabels(ls, p.ObjectMeta, RolePVC)
+
+	return ls
+}
+
+func (p *PersistentVolumeClaim) buildPVC(pvc *apiv1.PersistentVolumeClaim) *targetgroup.Group {
+	tg := &targetgroup.Group{
+		Source: pvcSource(pvc),
+	}
+	tg.Labels = pvcLabels(pvc)
+
+	if p.withNamespaceMetadata {
+		tg.Labels = addNamespaceLabels(tg.Labels, p.namespaceInf, p.logger, pvc.Namespace, p.namespaceLabelPrefixes)
+	}
+
+	// A PersistentVolumeClaim has no network address of its own; the name is
+	// used as the address so that relabeling can construct one if needed.
+	labelSet := model.LabelSet{
+		model.AddressLabel: lv(pvc.Name),
+		pvcPhaseLabel:      lv(string(pvc.Status.Phase)),
+	}
+
+	if pvc.Spec.StorageClassName != nil {
+		labelSet[pvcStorageClassLabel] = lv(*pvc.Spec.StorageClassName)
+	}
+
+	if pvc.Spec.VolumeName != "" {
+		labelSet[pvcVolumeNameLabel] = lv(pvc.Spec.VolumeName)
+	}
+
+	tg.Targets = append(tg.Targets, labelSet)
+
+	return tg
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+func makePVC(namespace string) *v1.PersistentVolumeClaim {
+	storageClass := "fast-ssd"
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testpvc",
+			Namespace: namespace,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			VolumeName:       "pvc-0123",
+		},
+		Status: v1.PersistentVolumeClaimStatus{
+			Phase: v1.ClaimBound,
+		},
+	}
+}
+
+func TestPVCDiscoveryAdd(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RolePVC, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makePVC("default")
+			c.CoreV1().PersistentVolumeClaims(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"pvc/default/testpvc": {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                        "testpvc",
+						"__meta_kubernetes_pvc_storageclass": "fast-ssd",
+						"__meta_kubernetes_pvc_phase":        "Bound",
+						"__meta_kubernetes_pvc_volume_name":  "pvc-0123",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_pvc_name":  "testpvc",
+					"__meta_kubernetes_namespace": "default",
+				},
+				Source: "pvc/default/testpvc",
+			},
+		},
+	}.Run(t)
+}
+
+func TestPVCDiscoveryDelete(t *testing.T) {
+	t.Parallel()
+	obj := makePVC("default")
+	n, c := makeDiscovery(RolePVC, NamespaceDiscovery{}, obj)
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			c.CoreV1().PersistentVolumeClaims(obj.Namespace).Delete(context.Background(), obj.Name, metav1.DeleteOptions{})
+		},
+		expectedMaxItems: 2,
+		expectedRes: map[string]*targetgroup.Group{
+			"pvc/default/testpvc": {
+				Source: "pvc/default/testpvc",
+			},
+		},
+	}.Run(t)
+}
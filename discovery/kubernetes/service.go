@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -33,16 +35,20 @@ import (
 
 // Service implements discovery of Kubernetes services.
 type Service struct {
-	logger                *slog.Logger
-	informer              cache.SharedIndexInformer
-	store                 cache.Store
-	queue                 *workqueue.Type
-	namespaceInf          cache.SharedInformer
-	withNamespaceMetadata bool
+	logger                  *slog.Logger
+	informer                cache.SharedIndexInformer
+	store                   cache.Store
+	queue                   *workqueue.Type
+	namespaceInf            cache.SharedInformer
+	withNamespaceMetadata   bool
+	namespaceLabelPrefixes  []string
+	withSpecHash            bool
+	withLoadBalancerIngress bool
+	appProtocols            []string
 }
 
 // NewService returns a new service discovery.
-func NewService(l *slog.Logger, inf cache.SharedIndexInformer, namespace cache.SharedInformer, eventCount *prometheus.CounterVec) *Service {
+func NewService(l *slog.Logger, inf cache.SharedIndexInformer, namespace cache.SharedInformer, eventCount *prometheus.CounterVec, includeSpecHash, includeLoadBalancerIngress bool, namespaceLabelPrefixes, appProtocols []string) *Service {
 	if l == nil {
 		l = promslog.NewNopLogger()
 	}
@@ -52,12 +58,16 @@ func NewService(l *slog.Logger, inf cache.SharedIndexInformer, namespace cache.S
 	svcDeleteCount := eventCount.WithLabelValues(RoleService.String(), MetricLabelRoleDelete)
 
 	s := &Service{
-		logger:                l,
-		informer:              inf,
-		store:                 inf.GetStore(),
-		queue:                 workqueue.NewNamed(RoleService.String()),
-		namespaceInf:          namespace,
-		withNamespaceMetadata: namespace != nil,
+		logger:                  l,
+		informer:                inf,
+		store:                   inf.GetStore(),
+		queue:                   workqueue.NewNamed(RoleService.String()),
+		namespaceInf:            namespace,
+		withNamespaceMetadata:   namespace != nil,
+		namespaceLabelPrefixes:  namespaceLabelPrefixes,
+		withSpecHash:            includeSpecHash,
+		withLoadBalancerIngress: includeLoadBalancerIngress,
+		appProtocols:            appProtocols,
 	}
 
 	_, err := s.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -188,34 +198,84 @@ func serviceSourceFromNamespaceAndName(namespace, name string) string {
 }
 
 const (
-	servicePortNameLabel     = metaLabelPrefix + "service_port_name"
-	servicePortNumberLabel   = metaLabelPrefix + "service_port_number"
-	servicePortProtocolLabel = metaLabelPrefix + "service_port_protocol"
-	serviceClusterIPLabel    = metaLabelPrefix + "service_cluster_ip"
-	serviceLoadBalancerIP    = metaLabelPrefix + "service_loadbalancer_ip"
-	serviceExternalNameLabel = metaLabelPrefix + "service_external_name"
-	serviceType              = metaLabelPrefix + "service_type"
+	servicePortNameLabel               = metaLabelPrefix + "service_port_name"
+	servicePortNumberLabel             = metaLabelPrefix + "service_port_number"
+	servicePortProtocolLabel           = metaLabelPrefix + "service_port_protocol"
+	servicePortAppProtocolLabel        = metaLabelPrefix + "service_port_app_protocol"
+	serviceClusterIPLabel              = metaLabelPrefix + "service_cluster_ip"
+	serviceClusterIPsLabel             = metaLabelPrefix + "service_cluster_ips"
+	serviceSessionAffinityLabel        = metaLabelPrefix + "service_session_affinity"
+	serviceLoadBalancerIP              = metaLabelPrefix + "service_loadbalancer_ip"
+	serviceLoadBalancerClass           = metaLabelPrefix + "service_loadbalancer_class"
+	serviceLoadBalancerIngressIP       = metaLabelPrefix + "service_loadbalancer_ingress_ip"
+	serviceLoadBalancerIngressHostname = metaLabelPrefix + "service_loadbalancer_ingress_hostname"
+	serviceExternalNameLabel           = metaLabelPrefix + "service_external_name"
+	serviceType                        = metaLabelPrefix + "service_type"
+	serviceSpecHashLabel               = metaLabelPrefix + "service_spec_hash"
 )
 
 func serviceLabels(svc *apiv1.Service) model.LabelSet {
 	ls := make(model.LabelSet)
 	ls[namespaceLabel] = lv(svc.Namespace)
+	if svc.Spec.SessionAffinity != "" {
+		ls[serviceSessionAffinityLabel] = lv(string(svc.Spec.SessionAffinity))
+	}
+	// One entry for single-stack services, one per IP family on dual-stack.
+	if len(svc.Spec.ClusterIPs) > 0 {
+		ls[serviceClusterIPsLabel] = lv(strings.Join(svc.Spec.ClusterIPs, ","))
+	}
 	addObjectMetaLabels(ls, svc.ObjectMeta, RoleService)
 
 	return ls
 }
 
+// serviceSpecHashParts returns the normalized scrape-relevant parts of a
+// service used for the spec hash label: addresses, ports and object labels.
+func serviceSpecHashParts(svc *apiv1.Service) []string {
+	parts := []string{svc.Spec.ClusterIP, svc.Spec.ExternalName, string(svc.Spec.Type)}
+	for _, port := range svc.Spec.Ports {
+		parts = append(parts, port.Name, strconv.FormatInt(int64(port.Port), 10), string(port.Protocol))
+	}
+	return append(parts, sortedObjectLabels(svc.Labels)...)
+}
+
+// appProtocolIncluded reports whether a port passes the app_protocols filter.
+// Ports without a declared appProtocol are always included.
+func appProtocolIncluded(appProtocols []string, appProtocol *string) bool {
+	if len(appProtocols) == 0 || appProtocol == nil {
+		return true
+	}
+	return slices.Contains(appProtocols, *appProtocol)
+}
+
 func (s *Service) buildService(svc *apiv1.Service) *targetgroup.Group {
 	tg := &targetgroup.Group{
 		Source: serviceSource(svc),
 	}
 	tg.Labels = serviceLabels(svc)
+	if s.withSpecHash {
+		tg.Labels[serviceSpecHashLabel] = specHash(serviceSpecHashParts(svc)...)
+	}
 
 	if s.withNamespaceMetadata {
-		tg.Labels = addNamespaceLabels(tg.Labels, s.namespaceInf, s.logger, svc.Namespace)
+		tg.Labels = addNamespaceLabels(tg.Labels, s.namespaceInf, s.logger, svc.Namespace, s.namespaceLabelPrefixes)
+	}
+
+	if svc.Spec.Type == apiv1.ServiceTypeExternalName && len(svc.Spec.Ports) == 0 {
+		// ExternalName services frequently declare no ports; still emit a
+		// target pointing at the CNAME so that it can be probed.
+		tg.Targets = append(tg.Targets, model.LabelSet{
+			model.AddressLabel:       lv(svc.Spec.ExternalName),
+			serviceType:              lv(string(svc.Spec.Type)),
+			serviceExternalNameLabel: lv(svc.Spec.ExternalName),
+		})
+		return tg
 	}
 
 	for _, port := range svc.Spec.Ports {
+		if !appProtocolIncluded(s.appProtocols, port.AppProtocol) {
+			continue
+		}
 		addr := net.JoinHostPort(svc.Name+"."+svc.Namespace+".svc", strconv.FormatInt(int64(port.Port), 10))
 
 		labelSet := model.LabelSet{
@@ -225,6 +285,9 @@ func (s *Service) buildService(svc *apiv1.Service) *targetgroup.Group {
 			servicePortProtocolLabel: lv(string(port.Protocol)),
 			serviceType:              lv(string(svc.Spec.Type)),
 		}
+		if port.AppProtocol != nil {
+			labelSet[servicePortAppProtocolLabel] = lv(*port.AppProtocol)
+		}
 
 		if svc.Spec.Type == apiv1.ServiceTypeExternalName {
 			labelSet[serviceExternalNameLabel] = lv(svc.Spec.ExternalName)
@@ -234,10 +297,48 @@ func (s *Service) buildService(svc *apiv1.Service) *targetgroup.Group {
 
 		if svc.Spec.Type == apiv1.ServiceTypeLoadBalancer {
 			labelSet[serviceLoadBalancerIP] = lv(svc.Spec.LoadBalancerIP)
+			if svc.Spec.LoadBalancerClass != nil {
+				labelSet[serviceLoadBalancerClass] = lv(*svc.Spec.LoadBalancerClass)
+			}
 		}
 
 		tg.Targets = append(tg.Targets, labelSet)
 	}
 
+	if s.withLoadBalancerIngress && svc.Spec.Type == apiv1.ServiceTypeLoadBalancer {
+		// Services whose load balancer has not been provisioned yet simply
+		// have no ingress entries and contribute no extra targets.
+		for _, ing := range svc.Status.LoadBalancer.Ingress {
+			addr := ing.IP
+			if addr == "" {
+				addr = ing.Hostname
+			}
+			if addr == "" {
+				continue
+			}
+			for _, port := range svc.Spec.Ports {
+				if !appProtocolIncluded(s.appProtocols, port.AppProtocol) {
+					continue
+				}
+				labelSet := model.LabelSet{
+					model.AddressLabel:       lv(net.JoinHostPort(addr, strconv.FormatInt(int64(port.Port), 10))),
+					servicePortNameLabel:     lv(port.Name),
+					servicePortNumberLabel:   lv(strconv.FormatInt(int64(port.Port), 10)),
+					servicePortProtocolLabel: lv(string(port.Protocol)),
+					serviceType:              lv(string(svc.Spec.Type)),
+				}
+				if port.AppProtocol != nil {
+					labelSet[servicePortAppProtocolLabel] = lv(*port.AppProtocol)
+				}
+				if ing.IP != "" {
+					labelSet[serviceLoadBalancerIngressIP] = lv(ing.IP)
+				} else {
+					labelSet[serviceLoadBalancerIngressHostname] = lv(ing.Hostname)
+				}
+				tg.Targets = append(tg.Targets, labelSet)
+			}
+		}
+	}
+
 	return tg
 }
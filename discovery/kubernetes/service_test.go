@@ -185,6 +185,241 @@ func TestServiceDiscoveryAdd(t *testing.T) {
 	}.Run(t)
 }
 
+func TestServiceDiscoverySessionAffinityAndClusterIPs(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleService, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeService("default")
+			obj.Spec.SessionAffinity = v1.ServiceAffinityClientIP
+			// A dual-stack service spans one cluster IP per IP family.
+			obj.Spec.ClusterIPs = []string{"10.0.0.1", "fd00::1"}
+			c.CoreV1().Services(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"svc/default/testservice": {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                             "testservice.default.svc:30900",
+						"__meta_kubernetes_service_type":          "ClusterIP",
+						"__meta_kubernetes_service_cluster_ip":    "10.0.0.1",
+						"__meta_kubernetes_service_port_name":     "testport",
+						"__meta_kubernetes_service_port_number":   "30900",
+						"__meta_kubernetes_service_port_protocol": "TCP",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_service_name":             "testservice",
+					"__meta_kubernetes_namespace":                "default",
+					"__meta_kubernetes_service_session_affinity": "ClientIP",
+					"__meta_kubernetes_service_cluster_ips":      "10.0.0.1,fd00::1",
+				},
+				Source: "svc/default/testservice",
+			},
+		},
+	}.Run(t)
+}
+
+func TestServiceDiscoveryAppProtocolFilter(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleService, NamespaceDiscovery{})
+	n.appProtocols = []string{"http", "grpc"}
+
+	httpProtocol := "http"
+	postgresProtocol := "postgresql"
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testservice",
+					Namespace: "default",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:        "metrics",
+							Protocol:    v1.ProtocolTCP,
+							Port:        int32(30900),
+							AppProtocol: &httpProtocol,
+						},
+						{
+							// No declared appProtocol, included by default.
+							Name:     "extra",
+							Protocol: v1.ProtocolTCP,
+							Port:     int32(30901),
+						},
+						{
+							Name:        "db",
+							Protocol:    v1.ProtocolTCP,
+							Port:        int32(5432),
+							AppProtocol: &postgresProtocol,
+						},
+					},
+					Type:      v1.ServiceTypeClusterIP,
+					ClusterIP: "10.0.0.1",
+				},
+			}
+			c.CoreV1().Services(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"svc/default/testservice": {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                                 "testservice.default.svc:30900",
+						"__meta_kubernetes_service_type":              "ClusterIP",
+						"__meta_kubernetes_service_cluster_ip":        "10.0.0.1",
+						"__meta_kubernetes_service_port_name":         "metrics",
+						"__meta_kubernetes_service_port_number":       "30900",
+						"__meta_kubernetes_service_port_protocol":     "TCP",
+						"__meta_kubernetes_service_port_app_protocol": "http",
+					},
+					{
+						"__address__":                             "testservice.default.svc:30901",
+						"__meta_kubernetes_service_type":          "ClusterIP",
+						"__meta_kubernetes_service_cluster_ip":    "10.0.0.1",
+						"__meta_kubernetes_service_port_name":     "extra",
+						"__meta_kubernetes_service_port_number":   "30901",
+						"__meta_kubernetes_service_port_protocol": "TCP",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_service_name": "testservice",
+					"__meta_kubernetes_namespace":    "default",
+				},
+				Source: "svc/default/testservice",
+			},
+		},
+	}.Run(t)
+}
+
+func TestServiceDiscoveryLoadBalancerClass(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleService, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeLoadBalancerService()
+			lbClass := "service.k8s.aws/nlb"
+			obj.Spec.LoadBalancerClass = &lbClass
+			c.CoreV1().Services(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"svc/default/testservice-loadbalancer": {
+				Targets: []model.LabelSet{
+					{
+						"__meta_kubernetes_service_port_protocol": "TCP",
+						"__address__":                                  "testservice-loadbalancer.default.svc:31900",
+						"__meta_kubernetes_service_type":               "LoadBalancer",
+						"__meta_kubernetes_service_port_name":          "testport",
+						"__meta_kubernetes_service_port_number":        "31900",
+						"__meta_kubernetes_service_cluster_ip":         "10.0.0.1",
+						"__meta_kubernetes_service_loadbalancer_ip":    "127.0.0.1",
+						"__meta_kubernetes_service_loadbalancer_class": "service.k8s.aws/nlb",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_service_name": "testservice-loadbalancer",
+					"__meta_kubernetes_namespace":    "default",
+				},
+				Source: "svc/default/testservice-loadbalancer",
+			},
+		},
+	}.Run(t)
+}
+
+func TestServiceDiscoveryLoadBalancerIngress(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleService, NamespaceDiscovery{})
+	n.loadBalancerIngressTargets = true
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeLoadBalancerService()
+			obj.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{
+				{IP: "192.0.2.1"},
+				{Hostname: "lb.example.com"},
+			}
+			c.CoreV1().Services(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"svc/default/testservice-loadbalancer": {
+				Targets: []model.LabelSet{
+					{
+						"__meta_kubernetes_service_port_protocol": "TCP",
+						"__address__":                               "testservice-loadbalancer.default.svc:31900",
+						"__meta_kubernetes_service_type":            "LoadBalancer",
+						"__meta_kubernetes_service_port_name":       "testport",
+						"__meta_kubernetes_service_port_number":     "31900",
+						"__meta_kubernetes_service_cluster_ip":      "10.0.0.1",
+						"__meta_kubernetes_service_loadbalancer_ip": "127.0.0.1",
+					},
+					{
+						"__meta_kubernetes_service_port_protocol": "TCP",
+						"__address__":                                       "192.0.2.1:31900",
+						"__meta_kubernetes_service_type":                    "LoadBalancer",
+						"__meta_kubernetes_service_port_name":               "testport",
+						"__meta_kubernetes_service_port_number":             "31900",
+						"__meta_kubernetes_service_loadbalancer_ingress_ip": "192.0.2.1",
+					},
+					{
+						"__meta_kubernetes_service_port_protocol": "TCP",
+						"__address__":                                             "lb.example.com:31900",
+						"__meta_kubernetes_service_type":                          "LoadBalancer",
+						"__meta_kubernetes_service_port_name":                     "testport",
+						"__meta_kubernetes_service_port_number":                   "31900",
+						"__meta_kubernetes_service_loadbalancer_ingress_hostname": "lb.example.com",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_service_name": "testservice-loadbalancer",
+					"__meta_kubernetes_namespace":    "default",
+				},
+				Source: "svc/default/testservice-loadbalancer",
+			},
+		},
+	}.Run(t)
+}
+
+func TestServiceDiscoveryExternalNameNoPorts(t *testing.T) {
+	t.Parallel()
+	n, c := makeDiscovery(RoleService, NamespaceDiscovery{})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeExternalService()
+			obj.Spec.Ports = nil
+			c.CoreV1().Services(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"svc/default/testservice-external": {
+				Targets: []model.LabelSet{
+					{
+						"__address__":                             "FooExternalName",
+						"__meta_kubernetes_service_type":          "ExternalName",
+						"__meta_kubernetes_service_external_name": "FooExternalName",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_service_name": "testservice-external",
+					"__meta_kubernetes_namespace":    "default",
+				},
+				Source: "svc/default/testservice-external",
+			},
+		},
+	}.Run(t)
+}
+
 func TestServiceDiscoveryDelete(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RoleService, NamespaceDiscovery{}, makeService("default"))
@@ -336,6 +571,41 @@ func TestServiceDiscoveryOwnNamespace(t *testing.T) {
 	}.Run(t)
 }
 
+func TestServiceDiscoveryOverlappingNamespacesDeduplicated(t *testing.T) {
+	t.Parallel()
+	// The own namespace is also listed explicitly; only one informer must be
+	// started for it, so each service is emitted exactly once.
+	n, c := makeDiscovery(RoleService, NamespaceDiscovery{Names: []string{"own-ns"}, IncludeOwnNamespace: true})
+
+	k8sDiscoveryTest{
+		discovery: n,
+		afterStart: func() {
+			obj := makeService("own-ns")
+			c.CoreV1().Services(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		},
+		expectedMaxItems: 1,
+		expectedRes: map[string]*targetgroup.Group{
+			"svc/own-ns/testservice": {
+				Targets: []model.LabelSet{
+					{
+						"__meta_kubernetes_service_port_protocol": "TCP",
+						"__address__":                           "testservice.own-ns.svc:30900",
+						"__meta_kubernetes_service_type":        "ClusterIP",
+						"__meta_kubernetes_service_cluster_ip":  "10.0.0.1",
+						"__meta_kubernetes_service_port_name":   "testport",
+						"__meta_kubernetes_service_port_number": "30900",
+					},
+				},
+				Labels: model.LabelSet{
+					"__meta_kubernetes_service_name": "testservice",
+					"__meta_kubernetes_namespace":    "own-ns",
+				},
+				Source: "svc/own-ns/testservice",
+			},
+		},
+	}.Run(t)
+}
+
 func TestServiceDiscoveryAllNamespaces(t *testing.T) {
 	t.Parallel()
 	n, c := makeDiscovery(RoleService, NamespaceDiscovery{})